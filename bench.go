@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/solver"
+	"infinity/miner/internal/utils"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func runBench(cfg *config.Loaded, args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	duration := flags.Duration("duration", 10*time.Second, "benchmark duration")
+	flags.Parse(args)
+
+	privateKeyA, err := crypto.GenerateKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// zero difficulty is never solved
+	problem := PoW.PoWNewProblem{
+		Nonce:       big.NewInt(0),
+		PrivateKeyA: privateKeyA.D,
+		Difficulty:  big.NewInt(0),
+		Raw:         &types.Log{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	solutionCh := make(chan solver.Solution)
+	solvers, _, err := solver.Start(ctx, cfg.Threads, cfg.ReserveCores, cfg.CPUAffinity, solutionCh)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, solver := range solvers {
+		solver.ProblemCh <- problem
+	}
+
+	log.Printf("Running %d solvers for %s", len(solvers), *duration)
+	startTime := time.Now()
+	<-ctx.Done()
+
+	totalTries := uint64(0)
+	for i, solver := range solvers {
+		tries := solver.NumTries.Load()
+		totalTries += tries
+		log.Printf("solver %d: %s", i, utils.FormatHashrate(utils.Hashrate(tries, startTime)))
+	}
+	log.Printf("total: %d tries, %s", totalTries, utils.FormatHashrate(utils.Hashrate(totalTries, startTime)))
+}
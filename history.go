@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/history"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const historyUsage = "usage: miner [flags] history problems|solutions|transactions|summary [-since t] [-until t] [-nonce n] [-status s] [-limit n] [-format table|csv|json]"
+
+func runHistory(cfg *config.Loaded, args []string) {
+	if len(args) == 0 {
+		log.Fatal(historyUsage)
+	}
+	kind := args[0]
+
+	flags := flag.NewFlagSet("history "+kind, flag.ExitOnError)
+	since := flags.String("since", "", "records from time (RFC3339 or 2006-01-02) or duration ago, e.g. 24h")
+	until := flags.String("until", "", "records before time (RFC3339 or 2006-01-02) or duration ago")
+	nonce := flags.String("nonce", "", "problem nonce")
+	status := flags.String("status", "", "transaction status: lost, won, reverted, failed, confirmed or unconfirmed")
+	limit := flags.Int("limit", 0, "show last n records, 0 - all")
+	format := flags.String("format", "table", "table, csv or json")
+	flags.Parse(args[1:])
+
+	if cfg.HistoryFile == "" {
+		log.Fatalf("history_file %s (INFINITY_HISTORY_FILE or -history-file)", config.ErrMissing)
+	}
+
+	filter := history.Filter{Status: *status, Limit: *limit}
+	var err error
+	if filter.Since, err = parseTime(*since); err != nil {
+		log.Fatal("-since: ", err)
+	}
+	if filter.Until, err = parseTime(*until); err != nil {
+		log.Fatal("-until: ", err)
+	}
+	if *nonce != "" {
+		var ok bool
+		if filter.Nonce, ok = new(big.Int).SetString(*nonce, 10); !ok {
+			log.Fatalf("-nonce: invalid number %q", *nonce)
+		}
+	}
+
+	store := history.Open(cfg.HistoryFile)
+	var records any
+	var header []string
+	var rows [][]string
+	switch kind {
+	case "problems":
+		problems, err := store.Problems(filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		records = problems
+		header = []string{"SEEN AT", "NONCE", "DIFFICULTY", "BLOCK"}
+		for _, problem := range problems {
+			rows = append(rows, []string{
+				formatTime(problem.SeenAt),
+				problem.Nonce.String(),
+				common.BigToAddress(problem.Difficulty).Hex(),
+				strconv.FormatUint(problem.Block, 10),
+			})
+		}
+	case "solutions":
+		solutions, err := store.Solutions(filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		records = solutions
+		header = []string{"FOUND AT", "NONCE", "ADDRESS AB", "SOLVE TIME"}
+		for _, solution := range solutions {
+			rows = append(rows, []string{
+				formatTime(solution.FoundAt),
+				solution.Nonce.String(),
+				solution.AddressAB.Hex(),
+				solution.SolveTime.Round(time.Millisecond).String(),
+			})
+		}
+	case "transactions":
+		transactions, err := store.Transactions(filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		records = transactions
+		header = []string{"SENT AT", "NONCE", "TX", "TX NONCE", "FEE", "STATUS", "REVERT REASON", "REWARD", "BLOCK"}
+		for _, transaction := range transactions {
+			sentAt := formatTime(transaction.SentAt)
+			if sentAt == "" && *format != "csv" {
+				sentAt = "unknown" // found by backfill
+			}
+			rows = append(rows, []string{
+				sentAt,
+				formatInt(transaction.Nonce),
+				transaction.Hash.Hex(),
+				strconv.FormatUint(transaction.TxNonce, 10),
+				formatEther(transaction.Fee),
+				transaction.Status,
+				transaction.RevertReason,
+				formatEther(transaction.Reward),
+				strconv.FormatUint(transaction.Block, 10),
+			})
+		}
+	case "summary":
+		summary, err := store.Summary(filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		records = summary
+		header = []string{"PROBLEMS", "SOLUTIONS", "TRANSACTIONS", "STATUSES", "FEES", "REWARDS", "AVG SOLVE TIME", "SINCE"}
+		statuses := make([]string, 0, len(summary.Statuses))
+		for status, n := range summary.Statuses {
+			statuses = append(statuses, fmt.Sprintf("%s(%d)", status, n))
+		}
+		sort.Strings(statuses)
+		rows = append(rows, []string{
+			strconv.FormatUint(summary.Problems, 10),
+			strconv.FormatUint(summary.Solutions, 10),
+			strconv.FormatUint(summary.Transactions, 10),
+			strings.Join(statuses, " "),
+			formatEther(summary.Fees),
+			formatEther(summary.Rewards),
+			summary.AvgSolveTime.Round(time.Millisecond).String(),
+			formatTime(summary.FirstSeen),
+		})
+	default:
+		log.Fatal(historyUsage)
+	}
+
+	switch *format {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		w.Flush()
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		for i, column := range header {
+			header[i] = strings.ToLower(strings.ReplaceAll(column, " ", "_"))
+		}
+		w.Write(header)
+		w.WriteAll(rows)
+		if err := w.Error(); err != nil {
+			log.Fatal(err)
+		}
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("-format is %q, expected table, csv or json", *format)
+	}
+}
+
+// parseTime parses RFC3339 time, date or duration before now.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateTime)
+}
+
+func formatInt(value *big.Int) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func formatEther(value *big.Int) string {
+	if value == nil {
+		return ""
+	}
+	return accounting.ToEther(value).Text('f', 6)
+}
@@ -0,0 +1,242 @@
+package accounting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const dayFormat = "2006-01-02"
+
+// Totals are kept in wei: rewards in INFINITY units, gas in $S.
+type Totals struct {
+	Wins     uint64 // confirmed submissions
+	Reverted uint64
+	Rewards  *big.Int
+	GasSpent *big.Int
+}
+
+func newTotals() *Totals {
+	return &Totals{Rewards: new(big.Int), GasSpent: new(big.Int)}
+}
+
+func (t *Totals) copy() Totals {
+	return Totals{
+		Wins:     t.Wins,
+		Reverted: t.Reverted,
+		Rewards:  new(big.Int).Set(t.Rewards),
+		GasSpent: new(big.Int).Set(t.GasSpent),
+	}
+}
+
+type Ledger struct {
+	mu      sync.Mutex
+	started time.Time
+	session *Totals
+	days    map[string]*Totals
+
+	// price of 1 INFINITY in $S, nil if unknown
+	price *big.Float
+}
+
+func NewLedger(price *big.Float) *Ledger {
+	return &Ledger{
+		started: time.Now(),
+		session: newTotals(),
+		days:    make(map[string]*Totals),
+		price:   price,
+	}
+}
+
+func (l *Ledger) day(t time.Time) *Totals {
+	key := t.UTC().Format(dayFormat)
+	totals, ok := l.days[key]
+	if !ok {
+		totals = newTotals()
+		l.days[key] = totals
+	}
+	return totals
+}
+
+func (l *Ledger) AddGas(t time.Time, gasCost *big.Int, reverted bool) {
+	if gasCost == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, totals := range []*Totals{l.session, l.day(t)} {
+		totals.GasSpent.Add(totals.GasSpent, gasCost)
+		if reverted {
+			totals.Reverted++
+		}
+	}
+}
+
+func (l *Ledger) AddReward(t time.Time, reward *big.Int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, totals := range []*Totals{l.session, l.day(t)} {
+		totals.Wins++
+		totals.Rewards.Add(totals.Rewards, reward)
+	}
+}
+
+func (l *Ledger) Session() Totals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.session.copy()
+}
+
+func (l *Ledger) Price() *big.Float {
+	return l.price
+}
+
+// NetProfit returns rewards minus gas in $S, or nil if price is unknown.
+func (l *Ledger) NetProfit(totals Totals) *big.Float {
+	if l.price == nil {
+		return nil
+	}
+	rewards := new(big.Float).Mul(ToEther(totals.Rewards), l.price)
+	return rewards.Sub(rewards, ToEther(totals.GasSpent))
+}
+
+func ToEther(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether))
+}
+
+// formatEther formats wei as exact decimal amount of ether.
+func formatEther(wei *big.Int) string {
+	return new(big.Rat).SetFrac(wei, big.NewInt(params.Ether)).FloatString(18)
+}
+
+func parseEther(text string) (*big.Int, error) {
+	amount, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", text)
+	}
+	amount.Mul(amount, new(big.Rat).SetInt64(params.Ether))
+	if !amount.IsInt() {
+		return nil, fmt.Errorf("invalid amount %q", text)
+	}
+	return amount.Num(), nil
+}
+
+type exportTotals struct {
+	Day       string `json:"day,omitempty"`
+	Wins      uint64 `json:"wins"`
+	Reverted  uint64 `json:"reverted"`
+	Rewards   string `json:"rewards"`
+	GasSpent  string `json:"gasSpent"`
+	NetProfit string `json:"netProfit,omitempty"`
+}
+
+type export struct {
+	Started time.Time      `json:"started"`
+	Price   string         `json:"price,omitempty"`
+	Session exportTotals   `json:"session"`
+	Days    []exportTotals `json:"days"`
+}
+
+func (l *Ledger) exportTotals(day string, totals Totals) exportTotals {
+	out := exportTotals{
+		Day:      day,
+		Wins:     totals.Wins,
+		Reverted: totals.Reverted,
+		Rewards:  formatEther(totals.Rewards),
+		GasSpent: formatEther(totals.GasSpent),
+	}
+	if netProfit := l.NetProfit(totals); netProfit != nil {
+		out.NetProfit = netProfit.Text('f', 18)
+	}
+	return out
+}
+
+// Export writes session and per day totals as JSON.
+func (l *Ledger) Export(path string) error {
+	l.mu.Lock()
+	out := export{
+		Started: l.started,
+		Session: l.exportTotals("", l.session.copy()),
+	}
+	if l.price != nil {
+		out.Price = l.price.Text('f', -1)
+	}
+	for day, totals := range l.days {
+		out.Days = append(out.Days, l.exportTotals(day, totals.copy()))
+	}
+	l.mu.Unlock()
+
+	sort.Slice(out.Days, func(i, j int) bool { return out.Days[i].Day < out.Days[j].Day })
+
+	raw, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load restores per day totals of previous runs from file written by Export,
+// so days spanning restarts are not reset. Missing file is not an error.
+func (l *Ledger) Load(path string) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var previous struct {
+		Days []struct {
+			exportTotals
+			Submissions uint64 `json:"submissions"` // older name of wins
+		} `json:"days"`
+	}
+	if err := json.Unmarshal(raw, &previous); err != nil {
+		return err
+	}
+
+	days := make(map[string]*Totals, len(previous.Days))
+	for _, day := range previous.Days {
+		if _, err := time.Parse(dayFormat, day.Day); err != nil {
+			return fmt.Errorf("invalid day %q", day.Day)
+		}
+		totals := &Totals{Wins: day.Wins + day.Submissions, Reverted: day.Reverted}
+		if totals.Rewards, err = parseEther(day.Rewards); err != nil {
+			return fmt.Errorf("%s: rewards: %w", day.Day, err)
+		}
+		if totals.GasSpent, err = parseEther(day.GasSpent); err != nil {
+			return fmt.Errorf("%s: gas spent: %w", day.Day, err)
+		}
+		days[day.Day] = totals
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for day, totals := range days {
+		current, ok := l.days[day]
+		if !ok {
+			l.days[day] = totals
+			continue
+		}
+		current.Wins += totals.Wins
+		current.Reverted += totals.Reverted
+		current.Rewards.Add(current.Rewards, totals.Rewards)
+		current.GasSpent.Add(current.GasSpent, totals.GasSpent)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package accounting
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadRestoresDays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounting.json")
+	day1 := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	reward, _ := new(big.Int).SetString("1234567890123456789", 10)
+
+	previous := NewLedger(nil)
+	previous.AddReward(day1, reward)
+	previous.AddGas(day1, big.NewInt(3), true)
+	previous.AddGas(day2, big.NewInt(5), false)
+	if err := previous.Export(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// day2 spans restart
+	ledger := NewLedger(nil)
+	ledger.AddGas(day2, big.NewInt(7), false)
+	if err := ledger.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if session := ledger.Session(); session.Wins != 0 || session.GasSpent.Int64() != 7 {
+		t.Fatalf("session includes previous run: %+v", session)
+	}
+
+	totals := ledger.days["2025-01-01"]
+	if totals == nil || totals.Wins != 1 || totals.Reverted != 1 || totals.Rewards.Cmp(reward) != 0 || totals.GasSpent.Int64() != 3 {
+		t.Fatalf("got %+v for the first day", totals)
+	}
+	totals = ledger.days["2025-01-02"]
+	if totals == nil || totals.GasSpent.Int64() != 12 {
+		t.Fatalf("got %+v for the second day", totals)
+	}
+}
+
+func TestLoadLegacyExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounting.json")
+	legacy := `{"days": [{"day": "2025-01-01", "submissions": 2, "reverted": 0, "rewards": "1.500000000000000000", "gasSpent": "0.000000000000000001"}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ledger := NewLedger(nil)
+	if err := ledger.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	totals := ledger.days["2025-01-01"]
+	want, _ := new(big.Int).SetString("1500000000000000000", 10)
+	if totals == nil || totals.Wins != 2 || totals.Rewards.Cmp(want) != 0 || totals.GasSpent.Int64() != 1 {
+		t.Fatalf("got %+v", totals)
+	}
+
+	if err := NewLedger(nil).Load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{"days": [{"day": "2025-01-01", "rewards": "0.1234567890123456789", "gasSpent": "0"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewLedger(nil).Load(path); err == nil {
+		t.Fatal("fraction of wei is accepted")
+	}
+}
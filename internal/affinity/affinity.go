@@ -0,0 +1,88 @@
+package affinity
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupported = errors.New("cpu pinning is not supported on this platform")
+
+// Plan returns CPUs for threads solvers. First reserve allowed CPUs are left
+// for other workloads, the rest are interleaved over NUMA nodes, so every node
+// gets equal share. If threads is 0, one solver per available CPU is planned.
+// If threads exceeds available CPUs, they are reused round robin.
+func Plan(threads int, reserve int) ([]int, error) {
+	allowed, err := AllowedCPUs()
+	if err != nil {
+		return nil, err
+	}
+	return plan(threads, reserve, allowed, NUMANodes())
+}
+
+func plan(threads int, reserve int, allowed []int, nodes [][]int) ([]int, error) {
+	allowed = slices.Sorted(slices.Values(allowed))
+	if reserve >= len(allowed) {
+		return nil, errors.New("all cpus are reserved")
+	}
+	available := make(map[int]bool)
+	for _, cpu := range allowed[reserve:] {
+		available[cpu] = true
+	}
+
+	var ordered []int
+	for i := 0; len(ordered) < len(available); i++ {
+		progress := false
+		for _, node := range nodes {
+			if i >= len(node) {
+				continue
+			}
+			progress = true
+			if available[node[i]] {
+				ordered = append(ordered, node[i])
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	// no NUMA info or cpus outside of known nodes
+	if len(ordered) < len(available) {
+		ordered = append(ordered[:0], allowed[reserve:]...)
+	}
+
+	if threads <= 0 {
+		threads = len(ordered)
+	}
+	plan := make([]int, threads)
+	for i := range plan {
+		plan[i] = ordered[i%len(ordered)]
+	}
+	return plan, nil
+}
+
+// parseCPUList parses kernel cpu list format, e.g. "0-3,8-11".
+func parseCPUList(raw string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(raw), ",") {
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil {
+				return nil, err
+			}
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
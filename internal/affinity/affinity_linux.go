@@ -0,0 +1,53 @@
+package affinity
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"golang.org/x/sys/unix"
+)
+
+func AllowedCPUs() ([]int, error) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return nil, err
+	}
+	var cpus []int
+	for cpu := range len(set) * 64 {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// NUMANodes returns CPUs of every NUMA node, nil if topology is unknown.
+func NUMANodes() [][]int {
+	paths, _ := filepath.Glob("/sys/devices/system/node/node[0-9]*/cpulist")
+	sort.Strings(paths)
+
+	var nodes [][]int
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		cpus, err := parseCPUList(string(raw))
+		if err != nil {
+			return nil
+		}
+		nodes = append(nodes, cpus)
+	}
+	return nodes
+}
+
+// Pin locks calling goroutine to its OS thread and binds the thread to cpu.
+func Pin(cpu int) error {
+	runtime.LockOSThread()
+
+	var set unix.CPUSet
+	set.Set(cpu)
+	return unix.SchedSetaffinity(0, &set)
+}
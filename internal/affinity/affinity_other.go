@@ -0,0 +1,21 @@
+//go:build !linux
+
+package affinity
+
+import "runtime"
+
+func AllowedCPUs() ([]int, error) {
+	cpus := make([]int, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i] = i
+	}
+	return cpus, nil
+}
+
+func NUMANodes() [][]int {
+	return nil
+}
+
+func Pin(cpu int) error {
+	return ErrUnsupported
+}
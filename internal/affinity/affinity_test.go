@@ -0,0 +1,55 @@
+package affinity
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseCPUList(t *testing.T) {
+	for _, test := range []struct {
+		raw  string
+		want []int
+		err  bool
+	}{
+		{raw: "0\n", want: []int{0}},
+		{raw: "0-3,8-9", want: []int{0, 1, 2, 3, 8, 9}},
+		{raw: "2,4-5,7", want: []int{2, 4, 5, 7}},
+		{raw: "", want: nil},
+		{raw: "3-1", want: nil},
+		{raw: "a", err: true},
+		{raw: "0-b", err: true},
+	} {
+		got, err := parseCPUList(test.raw)
+		if (err != nil) != test.err || !slices.Equal(got, test.want) {
+			t.Errorf("%q: got %v, %v, want %v, error %v", test.raw, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		threads int
+		reserve int
+		allowed []int
+		nodes   [][]int
+		want    []int
+		err     bool
+	}{
+		{name: "one per cpu", allowed: []int{3, 1, 0, 2}, want: []int{0, 1, 2, 3}},
+		{name: "reserved cpus excluded", reserve: 2, allowed: []int{3, 1, 0, 2}, want: []int{2, 3}},
+		{name: "threads reuse cpus", threads: 5, reserve: 1, allowed: []int{0, 1, 2}, want: []int{1, 2, 1, 2, 1}},
+		{name: "fewer threads", threads: 2, allowed: []int{0, 1, 2}, want: []int{0, 1}},
+		{name: "interleaved nodes", allowed: []int{0, 1, 2, 3}, nodes: [][]int{{0, 1}, {2, 3}}, want: []int{0, 2, 1, 3}},
+		{name: "reserved cpus excluded from nodes", reserve: 1, allowed: []int{0, 1, 2, 3}, nodes: [][]int{{0, 1}, {2, 3}}, want: []int{2, 1, 3}},
+		{name: "cpus outside of nodes", allowed: []int{0, 1, 2}, nodes: [][]int{{0}}, want: []int{0, 1, 2}},
+		{name: "all cpus are reserved", reserve: 2, allowed: []int{0, 1}, err: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := plan(test.threads, test.reserve, test.allowed, test.nodes)
+			if (err != nil) != test.err || !slices.Equal(got, test.want) {
+				t.Fatalf("got %v, %v, want %v, error %v", got, err, test.want, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package api serves local HTTP/JSON status and control endpoints.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+type Problem struct {
+	Nonce       string    `json:"nonce"`
+	Difficulty  string    `json:"difficulty"`
+	BlockNumber uint64    `json:"block_number"`
+	SeenAt      time.Time `json:"seen_at"`
+}
+
+type Submission struct {
+	ProblemNonce string    `json:"problem_nonce"`
+	TxHash       string    `json:"tx_hash,omitempty"`
+	Reverted     bool      `json:"reverted"`
+	RevertReason string    `json:"revert_reason,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+type Status struct {
+	Problem        *Problem    `json:"problem"`
+	Hashrate       float64     `json:"hashrate"` // 1 minute average, H/s
+	Hashrate10s    float64     `json:"hashrate_10s"`
+	Hashrate15m    float64     `json:"hashrate_15m"`
+	Threads        int         `json:"threads"`
+	Paused         bool        `json:"paused"`
+	PauseReason    string      `json:"pause_reason,omitempty"`
+	Balance        string      `json:"balance"` // in $S
+	LastSubmission *Submission `json:"last_submission"`
+}
+
+type Controller interface {
+	Status() Status
+	Pause()
+	Resume()
+	SetThreads(threads int) error
+}
+
+type server struct {
+	controller Controller
+	token      []byte
+}
+
+// Serve starts API on addr, every request must have "Authorization: Bearer <token>".
+// Returned func stops it.
+func Serve(addr string, token string, controller Controller) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &server{controller: controller, token: []byte(token)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.status)
+	mux.HandleFunc("POST /pause", s.pause)
+	mux.HandleFunc("POST /resume", s.resume)
+	mux.HandleFunc("POST /threads", s.threads)
+
+	server := &http.Server{Handler: s.auth(mux), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("API server stopped", "component", "api", "err", err)
+		}
+	}()
+	return func() { server.Close() }, nil
+}
+
+func (s *server) auth(next http.Handler) http.Handler {
+	expected := append([]byte("Bearer "), s.token...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) status(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func (s *server) pause(w http.ResponseWriter, _ *http.Request) {
+	s.controller.Pause()
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func (s *server) resume(w http.ResponseWriter, _ *http.Request) {
+	s.controller.Resume()
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func (s *server) threads(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Threads *int `json:"threads"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if request.Threads == nil || *request.Threads < 0 {
+		writeError(w, http.StatusBadRequest, errors.New(`expected {"threads": n}, n >= 0, 0 - one per available cpu`))
+		return
+	}
+	if err := s.controller.SetThreads(*request.Threads); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.controller.Status())
+}
+
+func writeJSON(w http.ResponseWriter, code int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"infinity/miner/internal/utils"
+	"math/big"
+	"net"
+	"net/url"
+	"runtime"
+	"time"
+)
+
+// Config is effective miner configuration. Values are taken from
+// (in increasing priority) defaults, config file, environment and flags.
+// Every field must have yaml, env and usage tags, flag name is yaml
+// name with dashes. Secret fields are redacted by Print, "url" ones
+// keep scheme and host.
+type Config struct {
+	RPC        string `yaml:"rpc" env:"INFINITY_RPC" usage:"HTTP RPC endpoint" secret:"url"`
+	WS         string `yaml:"ws" env:"INFINITY_WS" usage:"websocket RPC endpoint, used for problem subscription" secret:"url"`
+	PrivateKey string `yaml:"private_key" env:"INFINITY_PRIVATE_KEY" usage:"submitter private key, 0x is optional" secret:"true"`
+
+	Confirmations  uint64 `yaml:"confirmations" env:"INFINITY_CONFIRMATIONS" usage:"number of blocks before reward is counted as final"`
+	CursorFile     string `yaml:"cursor_file" env:"INFINITY_CURSOR_FILE" usage:"file with last processed block with confirmations, missed logs are replayed from it, empty - disabled"`
+	AccountingFile string `yaml:"accounting_file" env:"INFINITY_ACCOUNTING_FILE" usage:"export rewards, gas and profit to this JSON file"`
+	HistoryFile    string `yaml:"history_file" env:"INFINITY_HISTORY_FILE" usage:"database of problems, solutions and transactions, empty - disabled"`
+
+	SolutionLog           string `yaml:"solution_log" env:"INFINITY_SOLUTION_LOG" usage:"file, private keys of found solutions are appended to, empty - disabled"`
+	SolutionLogPassphrase string `yaml:"solution_log_passphrase" env:"INFINITY_SOLUTION_LOG_PASSPHRASE" usage:"encrypt solution log with this passphrase" secret:"true"`
+	SolutionLogPublicKey  string `yaml:"solution_log_public_key" env:"INFINITY_SOLUTION_LOG_PUBLIC_KEY" usage:"encrypt solution log for this NaCl box public key (hex), see solutions keygen"`
+
+	Threads      int  `yaml:"threads" env:"INFINITY_THREADS" usage:"number of solver threads, 0 - one per available cpu"`
+	ReserveCores int  `yaml:"reserve_cores" env:"INFINITY_RESERVE_CORES" usage:"number of cpus left for other workloads"`
+	CPUAffinity  bool `yaml:"cpu_affinity" env:"INFINITY_CPU_AFFINITY" usage:"pin every solver thread to its own cpu (linux only)"`
+
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"INFINITY_SHUTDOWN_TIMEOUT" usage:"how long to wait for in-flight submissions on shutdown"`
+	MetricsAddr     string        `yaml:"metrics_addr" env:"INFINITY_METRICS_ADDR" usage:"listen address of prometheus /metrics endpoint, e.g. :9100, empty - disabled"`
+	APIAddr         string        `yaml:"api_addr" env:"INFINITY_API_ADDR" usage:"localhost address of status and control API, e.g. 127.0.0.1:9101, empty - disabled"`
+	APIToken        string        `yaml:"api_token" env:"INFINITY_API_TOKEN" usage:"bearer token required by API" secret:"true"`
+
+	WebhookURL         string        `yaml:"webhook_url" env:"INFINITY_WEBHOOK_URL" usage:"URL, events are POSTed to, empty - disabled" secret:"true"`
+	WebhookTemplate    string        `yaml:"webhook_template" env:"INFINITY_WEBHOOK_TEMPLATE" usage:"Go template of webhook body, default - JSON of event"`
+	WebhookEvents      string        `yaml:"webhook_events" env:"INFINITY_WEBHOOK_EVENTS" usage:"comma separated events to send, empty - all"`
+	LowBalance         *big.Float    `yaml:"low_balance" env:"INFINITY_LOW_BALANCE" usage:"notify when submitter balance in $S drops below"`
+	ListenerAlertAfter time.Duration `yaml:"listener_alert_after" env:"INFINITY_LISTENER_ALERT_AFTER" usage:"notify when listener is disconnected longer than this"`
+
+	CoordinatorAddr string `yaml:"coordinator_addr" env:"INFINITY_COORDINATOR_ADDR" usage:"address coordinator listens on and workers connect to, e.g. 10.0.0.1:9200"`
+	FarmToken       string `yaml:"farm_token" env:"INFINITY_FARM_TOKEN" usage:"shared secret of coordinator and workers" secret:"true"`
+
+	LogLevel  string `yaml:"log_level" env:"INFINITY_LOG_LEVEL" usage:"debug, info, warn or error"`
+	LogFormat string `yaml:"log_format" env:"INFINITY_LOG_FORMAT" usage:"text or json"`
+
+	Price       *big.Float `yaml:"price" env:"INFINITY_PRICE" usage:"price of 1 INFINITY in $S"`
+	ProfitGuard string     `yaml:"profit_guard" env:"INFINITY_PROFIT_GUARD" usage:"off, skip (unprofitable submissions) or pause (mining)"`
+	MinProfit   *big.Float `yaml:"min_profit" env:"INFINITY_MIN_PROFIT" usage:"minimal profit of submission in $S"`
+}
+
+func Default() *Config {
+	return &Config{
+		Confirmations: 2,
+		CursorFile:    "listener.cursor",
+		HistoryFile:   "history.db",
+		SolutionLog:   "solution.log",
+		ProfitGuard:   "off",
+		MinProfit:     new(big.Float),
+
+		ShutdownTimeout: 30 * time.Second,
+
+		ListenerAlertAfter: time.Minute,
+
+		LogLevel:  "info",
+		LogFormat: "text",
+	}
+}
+
+var ErrMissing = errors.New("is not set")
+
+// Validate checks values needed for mining.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.RPC == "" {
+		errs = append(errs, fmt.Errorf("rpc %w (INFINITY_RPC or -rpc)", ErrMissing))
+	}
+	if c.WS == "" {
+		errs = append(errs, fmt.Errorf("ws %w (INFINITY_WS or -ws)", ErrMissing))
+	}
+
+	if c.PrivateKey == "" {
+		errs = append(errs, fmt.Errorf("private_key %w (INFINITY_PRIVATE_KEY or -private-key)", ErrMissing))
+	} else if _, err := utils.HexToPrivateKey(c.PrivateKey); err != nil {
+		errs = append(errs, fmt.Errorf("invalid private_key: %w", err))
+	}
+
+	switch c.ProfitGuard {
+	case "off":
+	case "skip", "pause":
+		if c.Price == nil {
+			errs = append(errs, errors.New("profit_guard requires price of INFINITY (INFINITY_PRICE or -price)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("profit_guard is %q, expected off, skip or pause", c.ProfitGuard))
+	}
+	if c.APIAddr != "" {
+		if c.APIToken == "" {
+			errs = append(errs, fmt.Errorf("api_token %w (INFINITY_API_TOKEN or -api-token), it is required by api_addr", ErrMissing))
+		}
+		if !isLoopback(c.APIAddr) {
+			errs = append(errs, fmt.Errorf("api_addr is %q, expected localhost address, e.g. 127.0.0.1:9101", c.APIAddr))
+		}
+	}
+	if c.WebhookURL != "" {
+		if u, err := url.Parse(c.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("webhook_url must be http or https URL"))
+		}
+	}
+	if c.SolutionLogPassphrase != "" && c.SolutionLogPublicKey != "" {
+		errs = append(errs, errors.New("solution_log_passphrase and solution_log_public_key can't be used together"))
+	}
+	if raw, err := hex.DecodeString(c.SolutionLogPublicKey); err != nil || (len(raw) != 32 && len(raw) != 0) {
+		errs = append(errs, errors.New("solution_log_public_key must be 64 hex symbols"))
+	}
+	if c.Threads < 0 {
+		errs = append(errs, errors.New("threads must not be negative"))
+	}
+	if c.ReserveCores < 0 || c.ReserveCores >= runtime.NumCPU() {
+		errs = append(errs, fmt.Errorf("reserve_cores must be between 0 and %d", runtime.NumCPU()-1))
+	}
+
+	if c.Price != nil && c.Price.Sign() < 0 {
+		errs = append(errs, errors.New("price must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateFarm checks values needed by coordinator and worker.
+func (c *Config) ValidateFarm() error {
+	var errs []error
+	if c.CoordinatorAddr == "" {
+		errs = append(errs, fmt.Errorf("coordinator_addr %w (INFINITY_COORDINATOR_ADDR or -coordinator-addr)", ErrMissing))
+	} else if _, _, err := net.SplitHostPort(c.CoordinatorAddr); err != nil {
+		errs = append(errs, fmt.Errorf("coordinator_addr is %q, expected host:port", c.CoordinatorAddr))
+	}
+	if c.FarmToken == "" {
+		errs = append(errs, fmt.Errorf("farm_token %w (INFINITY_FARM_TOKEN or -farm-token)", ErrMissing))
+	}
+	return errors.Join(errs...)
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"infinity/miner/internal"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultFile = "miner.yaml"
+
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+type field struct {
+	name   string // yaml name
+	env    string
+	flag   string
+	usage  string
+	secret string // "true" or "url"
+	value  reflect.Value
+}
+
+func fields(c *Config) []field {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	out := make([]field, 0, t.NumField())
+	for i := range t.NumField() {
+		tag := t.Field(i).Tag
+		name := tag.Get("yaml")
+		out = append(out, field{
+			name:   name,
+			env:    tag.Get("env"),
+			flag:   strings.ReplaceAll(name, "_", "-"),
+			usage:  tag.Get("usage"),
+			secret: tag.Get("secret"),
+			value:  v.Field(i),
+		})
+	}
+	return out
+}
+
+func (f field) set(raw string) error {
+	if f.value.Kind() == reflect.Pointer {
+		// empty value unsets optional field, e.g. price
+		if raw == "" {
+			f.value.SetZero()
+			return nil
+		}
+		if unmarshaler, ok := reflect.New(f.value.Type().Elem()).Interface().(encoding.TextUnmarshaler); ok {
+			if err := unmarshaler.UnmarshalText([]byte(raw)); err != nil {
+				return err
+			}
+			f.value.Set(reflect.ValueOf(unmarshaler))
+			return nil
+		}
+	}
+
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		if f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return err
+			}
+			f.value.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(n)
+	case reflect.Uint, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+	return nil
+}
+
+func (f field) String() string {
+	if f.value.Kind() == reflect.Pointer {
+		if f.value.IsNil() {
+			return ""
+		}
+		if marshaler, ok := f.value.Interface().(encoding.TextMarshaler); ok {
+			text, _ := marshaler.MarshalText()
+			return string(text)
+		}
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+// flagValue adapts field to flag.Value, so -help shows all options.
+type flagValue struct{ field }
+
+func (f flagValue) Set(raw string) error { return f.set(raw) }
+
+func (f flagValue) IsBoolFlag() bool {
+	return f.value.IsValid() && f.value.Kind() == reflect.Bool
+}
+
+func (f flagValue) String() string {
+	// flag package calls String on zero value to detect defaults
+	if !f.value.IsValid() {
+		return ""
+	}
+	return f.field.String()
+}
+
+type Loaded struct {
+	*Config
+	File    string // empty if config file is not used
+	Sources map[string]Source
+}
+
+// Load builds config from defaults, config file, .env file, environment and args.
+// Returns arguments left after flags, e.g. subcommand.
+func Load(args []string) (*Loaded, []string, error) {
+	loaded := &Loaded{Config: Default(), Sources: make(map[string]Source)}
+	configFields := fields(loaded.Config)
+	for _, f := range configFields {
+		loaded.Sources[f.name] = SourceDefault
+	}
+
+	// .env is optional, existing environment has priority over it
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	// flags have the highest priority, so they are parsed into scratch config
+	// and applied after file and environment
+	scratch := Default()
+	scratchFields := fields(scratch)
+	flags := flag.NewFlagSet("miner", flag.ContinueOnError)
+	configFile := flags.String("config", "", "config file (YAML), default "+defaultFile+" if exists (INFINITY_CONFIG)")
+	for _, f := range scratchFields {
+		flags.Var(flagValue{f}, f.flag, f.usage+" ("+f.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// file
+	explicit := true
+	loaded.File = *configFile
+	if loaded.File == "" {
+		loaded.File = os.Getenv("INFINITY_CONFIG")
+	}
+	if loaded.File == "" {
+		loaded.File, explicit = defaultFile, false
+	}
+	if err := loaded.loadFile(explicit); err != nil {
+		return nil, nil, err
+	}
+
+	// environment, empty variable is explicit value for strings (e.g. disables
+	// history_file) and optional fields, for other types it is ignored
+	for _, f := range configFields {
+		raw, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+		if raw == "" && f.value.Kind() != reflect.String && f.value.Kind() != reflect.Pointer {
+			continue
+		}
+		if err := f.set(raw); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %w", f.env, err)
+		}
+		loaded.Sources[f.name] = SourceEnv
+	}
+
+	// flags
+	flags.Visit(func(fl *flag.Flag) {
+		for i, f := range scratchFields {
+			if f.flag == fl.Name {
+				configFields[i].value.Set(f.value)
+				loaded.Sources[f.name] = SourceFlag
+			}
+		}
+	})
+
+	return loaded, flags.Args(), nil
+}
+
+func (l *Loaded) loadFile(explicit bool) error {
+	raw, err := os.ReadFile(l.File)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		l.File = ""
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	// values are taken as written, decoding into Go values would change
+	// them, e.g. 0123 is octal and 1e3 is float
+	var present map[string]yaml.Node
+	if err := yaml.Unmarshal(raw, &present); err != nil {
+		return fmt.Errorf("parse config %s: %w", l.File, err)
+	}
+
+	for _, f := range fields(l.Config) {
+		node, ok := present[f.name]
+		if !ok {
+			continue
+		}
+		delete(present, f.name)
+		if node.Kind != yaml.ScalarNode {
+			return fmt.Errorf("config %s: invalid %s: expected single value", l.File, f.name)
+		}
+		if node.Tag == "!!null" {
+			continue
+		}
+		if err := f.set(node.Value); err != nil {
+			return fmt.Errorf("config %s: invalid %s: %w", l.File, f.name, err)
+		}
+		l.Sources[f.name] = SourceFile
+	}
+	for name := range present {
+		return fmt.Errorf("config %s: unknown option %q", l.File, name)
+	}
+	return nil
+}
+
+// Print writes effective values with their sources, secrets are redacted.
+func (l *Loaded) Print(w io.Writer) {
+	if l.File != "" {
+		fmt.Fprintf(w, "# config file: %s\n", l.File)
+	}
+	for _, f := range fields(l.Config) {
+		value := f.String()
+		switch {
+		case value == "":
+		case f.secret == "url":
+			if redacted := internal.RedactEndpoint(value); redacted != value {
+				value = redacted + "/<redacted>"
+			}
+		case f.secret != "":
+			value = "<redacted>"
+		}
+		fmt.Fprintf(w, "%s: %q # %s\n", f.name, value, l.Sources[f.name])
+	}
+}
@@ -2,3 +2,4 @@ package internal
 
 const PoWAddress = "0x8888FF459Da48e5c9883f893fc8653c8E55F8888"
 const Data = "0x6370752d676f" // cpu-go
+const ChainID = 146           // sonic mainnet
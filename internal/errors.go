@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// DialError is returned when node endpoint can't be reached.
+type DialError struct {
+	Endpoint string
+	Err      error
+}
+
+func NewDialError(endpoint string, err error) *DialError {
+	return &DialError{Endpoint: RedactEndpoint(endpoint), Err: err}
+}
+
+// RedactEndpoint strips everything but scheme and host from endpoint URL,
+// endpoints often carry api keys in path, query or user info.
+func RedactEndpoint(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host
+	}
+	return endpoint
+}
+
+func (e *DialError) Error() string {
+	return fmt.Sprintf("dial %s: %s", e.Endpoint, e.Err)
+}
+
+func (e *DialError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned when value from config or chain can't be parsed.
+type ParseError struct {
+	What string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse %s: %s", e.What, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
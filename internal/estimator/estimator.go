@@ -0,0 +1,84 @@
+package estimator
+
+import (
+	"infinity/miner/internal/utils"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// number of last problem gaps used for estimation
+const windowSize = 32
+
+// Estimator estimates network hashrate from gaps between NewProblem events:
+// every gap is the time network needed to do ExpectedTries(difficulty) tries.
+type Estimator struct {
+	mu          sync.Mutex
+	lastProblem time.Time
+	difficulty  *big.Int
+	tries       []float64
+	gaps        []time.Duration
+}
+
+func NewEstimator() *Estimator {
+	return &Estimator{}
+}
+
+func (e *Estimator) ObserveProblem(seenAt time.Time, difficulty *big.Int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.lastProblem.IsZero() && seenAt.After(e.lastProblem) {
+		e.tries = append(e.tries, utils.ExpectedTries(e.difficulty))
+		e.gaps = append(e.gaps, seenAt.Sub(e.lastProblem))
+		if len(e.gaps) > windowSize {
+			e.tries = e.tries[1:]
+			e.gaps = e.gaps[1:]
+		}
+	}
+	e.lastProblem = seenAt
+	e.difficulty = difficulty
+}
+
+// NetworkHashrate returns estimated hashrate of all miners in H/s, 0 if unknown.
+func (e *Estimator) NetworkHashrate() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var tries float64
+	var elapsed time.Duration
+	for i := range e.gaps {
+		tries += e.tries[i]
+		elapsed += e.gaps[i]
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return tries / elapsed.Seconds()
+}
+
+// WinProbability returns chance to solve current problem first with hashrate.
+func (e *Estimator) WinProbability(hashrate float64) float64 {
+	networkHashrate := e.NetworkHashrate()
+	if networkHashrate <= 0 {
+		return 0
+	}
+	return math.Min(hashrate/networkHashrate, 1)
+}
+
+// ExpectedTimeToSolve returns mean time to solve current problem with hashrate alone.
+func (e *Estimator) ExpectedTimeToSolve(hashrate float64) time.Duration {
+	e.mu.Lock()
+	difficulty := e.difficulty
+	e.mu.Unlock()
+
+	if hashrate <= 0 {
+		return 0
+	}
+	seconds := utils.ExpectedTries(difficulty) / hashrate
+	if seconds >= math.MaxInt64/float64(time.Second) {
+		return math.MaxInt64
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,207 @@
+package farm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/solver"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testToken = "secret"
+
+// any address solves problem with this difficulty, no address solves it with zero
+var easyDifficulty = new(big.Int).Lsh(big.NewInt(1), 160)
+
+func newTestProblem(t *testing.T, nonce int64, difficulty *big.Int) PoW.PoWNewProblem {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return PoW.PoWNewProblem{
+		Nonce:       big.NewInt(nonce),
+		PrivateKeyA: key.D,
+		Difficulty:  difficulty,
+		Raw:         &types.Log{BlockNumber: uint64(nonce)},
+	}
+}
+
+func listen(t *testing.T, ctx context.Context) (*Server, chan solver.Solution) {
+	t.Helper()
+	solutions := make(chan solver.Solution, 16)
+	server, err := Listen(ctx, "127.0.0.1:0", testToken, solutions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, solutions
+}
+
+// dial connects as worker and completes handshake.
+func dial(t *testing.T, server *Server) (net.Conn, *json.Decoder, *json.Encoder) {
+	t.Helper()
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+	if err := encoder.Encode(Message{Type: TypeHello, Token: testToken, Name: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	var welcome Message
+	if err := decoder.Decode(&welcome); err != nil {
+		t.Fatal(err)
+	}
+	if welcome.Type != TypeWelcome {
+		t.Fatalf("got %q, want welcome", welcome.Type)
+	}
+	return conn, decoder, encoder
+}
+
+func receive(t *testing.T, solutions <-chan solver.Solution) solver.Solution {
+	t.Helper()
+	select {
+	case solution := <-solutions:
+		return solution
+	case <-time.After(10 * time.Second):
+		t.Fatal("no solution")
+		return solver.Solution{}
+	}
+}
+
+func TestWorkerWrongToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, _ := listen(t, ctx)
+
+	pool := solver.NewPool(ctx, 0, false, make(chan solver.Solution))
+	err := RunWorker(ctx, server.Addr().String(), "wrong", "test", pool, make(chan solver.Solution))
+	if !errors.Is(err, ErrRejected) {
+		t.Fatalf("got %v, want ErrRejected", err)
+	}
+}
+
+func TestWorkerSolvesPushedProblem(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, solutions := listen(t, ctx)
+	problem := newTestProblem(t, 1, easyDifficulty)
+	server.SetProblem(problem)
+
+	solutionCh := make(chan solver.Solution, 1)
+	pool := solver.NewPool(ctx, 0, false, solutionCh)
+	if _, err := pool.Resize(1); err != nil {
+		t.Fatal(err)
+	}
+	workerErr := make(chan error, 1)
+	go func() {
+		workerErr <- RunWorker(ctx, server.Addr().String(), testToken, "test", pool, solutionCh)
+	}()
+
+	solution := receive(t, solutions)
+	if solution.Nonce.Cmp(problem.Nonce) != 0 {
+		t.Fatalf("got nonce %v, want %v", &solution.Nonce, problem.Nonce)
+	}
+	if _, ok, err := solver.Verify(solution.PrivateKeyA, solution.PrivateKeyB, problem.Difficulty); err != nil || !ok {
+		t.Fatalf("invalid solution: %v", err)
+	}
+	if workers := server.Workers(); len(workers) != 1 || workers[0].Name != "test" || workers[0].Solutions == 0 {
+		t.Fatalf("unexpected workers %+v", workers)
+	}
+
+	cancel()
+	if err := <-workerErr; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerDropsStaleAndInvalidSolutions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, solutions := listen(t, ctx)
+	_, decoder, encoder := dial(t, server)
+
+	hard := newTestProblem(t, 1, new(big.Int))
+	server.SetProblem(hard)
+	var message Message
+	if err := decoder.Decode(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != TypeProblem || message.Problem.Nonce.Cmp(hard.Nonce) != 0 || message.Problem.PrivateKeyA.Cmp(hard.PrivateKeyA) != 0 {
+		t.Fatalf("unexpected problem push %+v", message)
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, solution := range []Solution{
+		{Nonce: big.NewInt(0), PrivateKeyB: key.D}, // stale
+		{Nonce: hard.Nonce, PrivateKeyB: key.D},    // doesn't solve problem
+		{Nonce: hard.Nonce, PrivateKeyB: new(big.Int).Lsh(big.NewInt(1), 256)},
+		{Nonce: hard.Nonce},
+	} {
+		if err := encoder.Encode(Message{Type: TypeSolution, Solution: &solution}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// solutions of connection are handled in order, so the first one accepted must be the valid one
+	easy := newTestProblem(t, 2, easyDifficulty)
+	server.SetProblem(easy)
+	if err := decoder.Decode(&message); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Encode(Message{Type: TypeSolution, Solution: &Solution{Nonce: easy.Nonce, PrivateKeyB: key.D}}); err != nil {
+		t.Fatal(err)
+	}
+	solution := receive(t, solutions)
+	if solution.Nonce.Cmp(easy.Nonce) != 0 || solution.PrivateKeyB.D.Cmp(key.D) != 0 {
+		t.Fatalf("got solution for nonce %v, want the valid one", &solution.Nonce)
+	}
+	select {
+	case solution := <-solutions:
+		t.Fatalf("unexpected solution for nonce %v", &solution.Nonce)
+	default:
+	}
+}
+
+func TestServerLimitsMessageSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, _ := listen(t, ctx)
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	// endless hello, server must give up instead of buffering it
+	go func() {
+		conn.Write([]byte(`{"type":"hello","name":"`))
+		chunk := make([]byte, 4096)
+		for i := range chunk {
+			chunk[i] = 'a'
+		}
+		for {
+			if _, err := conn.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+	var message Message
+	if err := json.NewDecoder(conn).Decode(&message); err == nil {
+		t.Fatalf("got %+v, want closed connection", message)
+	}
+}
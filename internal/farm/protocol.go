@@ -0,0 +1,93 @@
+// Package farm distributes problems from coordinator, which holds chain
+// connection and submitter, to workers, which only run solvers.
+//
+// Coordinator and workers exchange JSON messages, one per line, over TCP.
+// Worker starts with hello, coordinator answers welcome (or error and closes
+// connection), then pushes every new problem. Worker sends solutions and
+// periodic stats, which also keep connection alive.
+package farm
+
+import (
+	"errors"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/hashrate"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Message types
+const (
+	TypeHello    = "hello"
+	TypeWelcome  = "welcome"
+	TypeError    = "error"
+	TypeProblem  = "problem"
+	TypeSolution = "solution"
+	TypeStats    = "stats"
+)
+
+const (
+	statsInterval    = 10 * time.Second
+	readTimeout      = 3 * statsInterval
+	handshakeTimeout = 10 * time.Second
+	writeTimeout     = 10 * time.Second
+)
+
+type Message struct {
+	Type     string          `json:"type"`
+	Token    string          `json:"token,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Threads  int             `json:"threads,omitempty"`
+	Problem  *Problem        `json:"problem,omitempty"`
+	Solution *Solution       `json:"solution,omitempty"`
+	Tries    uint64          `json:"tries,omitempty"`
+	Hashrate *hashrate.Rates `json:"hashrate,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+type Problem struct {
+	Nonce       *big.Int `json:"nonce"`
+	PrivateKeyA *big.Int `json:"private_key_a"`
+	Difficulty  *big.Int `json:"difficulty"`
+	Block       uint64   `json:"block"`
+}
+
+type Solution struct {
+	Nonce       *big.Int `json:"nonce"`
+	PrivateKeyB *big.Int `json:"private_key_b"`
+}
+
+func newProblem(problem PoW.PoWNewProblem) *Problem {
+	p := &Problem{Nonce: problem.Nonce, PrivateKeyA: problem.PrivateKeyA, Difficulty: problem.Difficulty}
+	if problem.Raw != nil {
+		p.Block = problem.Raw.BlockNumber
+	}
+	return p
+}
+
+func (p *Problem) pow() (PoW.PoWNewProblem, error) {
+	if p.Nonce == nil || p.PrivateKeyA == nil || p.Difficulty == nil {
+		return PoW.PoWNewProblem{}, errors.New("incomplete problem")
+	}
+	if p.PrivateKeyA.Sign() < 0 || p.PrivateKeyA.BitLen() > 256 {
+		return PoW.PoWNewProblem{}, errors.New("invalid private key A")
+	}
+	return PoW.PoWNewProblem{
+		Nonce:       p.Nonce,
+		PrivateKeyA: p.PrivateKeyA,
+		Difficulty:  p.Difficulty,
+		Raw:         &types.Log{BlockNumber: p.Block},
+	}, nil
+}
+
+// WorkerStats are reported by connected worker.
+type WorkerStats struct {
+	Name        string
+	Addr        string
+	Threads     int
+	Tries       uint64
+	Solutions   uint64 // accepted by coordinator
+	Hashrate    hashrate.Rates
+	ConnectedAt time.Time
+}
@@ -0,0 +1,249 @@
+package farm
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/solver"
+	"infinity/miner/internal/utils"
+	"io"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// messages queued for worker, it is disconnected when queue is full
+	queueSize = 16
+	// limits data read per message, so peer can't exhaust memory with endless message
+	maxMessageSize = 64 << 10
+)
+
+// Server is coordinator side of farm, it pushes problems to workers
+// and sends their verified solutions to solution channel.
+type Server struct {
+	token     []byte
+	solutions chan<- solver.Solution
+	listener  net.Listener
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	problem *PoW.PoWNewProblem
+	workers map[*worker]struct{}
+}
+
+type worker struct {
+	conn  net.Conn
+	out   chan Message
+	stats WorkerStats // guarded by Server.mu
+}
+
+// Listen accepts workers on addr until ctx is canceled.
+func Listen(ctx context.Context, addr string, token string, solutions chan<- solver.Solution) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		token:     []byte(token),
+		solutions: solutions,
+		listener:  listener,
+		logger:    slog.With("component", "coordinator"),
+		workers:   make(map[*worker]struct{}),
+	}
+	context.AfterFunc(ctx, s.close)
+	go s.accept(ctx)
+	return s, nil
+}
+
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+func (s *Server) close() {
+	s.listener.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for w := range s.workers {
+		w.conn.Close()
+	}
+}
+
+func (s *Server) accept(ctx context.Context) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Cant accept worker", "err", err)
+			}
+			return
+		}
+		go s.serve(ctx, conn)
+	}
+}
+
+// SetProblem pushes problem to all workers and to workers connected later.
+func (s *Server) SetProblem(problem PoW.PoWNewProblem) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.problem = &problem
+	for w := range s.workers {
+		s.send(w, Message{Type: TypeProblem, Problem: newProblem(problem)})
+	}
+}
+
+// send queues message, slow worker is disconnected. Must be called with mu held.
+func (s *Server) send(w *worker, message Message) {
+	select {
+	case w.out <- message:
+	default:
+		s.logger.Warn("Worker is too slow, disconnecting", "worker", w.stats.Name)
+		w.conn.Close()
+	}
+}
+
+// Workers are ordered by connection time.
+func (s *Server) Workers() []WorkerStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]WorkerStats, 0, len(s.workers))
+	for w := range s.workers {
+		stats = append(stats, w.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ConnectedAt.Before(stats[j].ConnectedAt)
+	})
+	return stats
+}
+
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	reader := &io.LimitedReader{R: conn, N: maxMessageSize}
+	decoder := json.NewDecoder(reader)
+	encoder := json.NewEncoder(conn)
+
+	var hello Message
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := decoder.Decode(&hello); err != nil || hello.Type != TypeHello {
+		s.logger.Warn("Worker handshake failed", "addr", conn.RemoteAddr(), "err", err)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hello.Token), s.token) != 1 {
+		s.logger.Warn("Worker token is invalid", "addr", conn.RemoteAddr(), "worker", hello.Name)
+		encoder.Encode(Message{Type: TypeError, Error: "invalid token"})
+		return
+	}
+	if err := encoder.Encode(Message{Type: TypeWelcome}); err != nil {
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	w := &worker{
+		conn: conn,
+		out:  make(chan Message, queueSize),
+		stats: WorkerStats{
+			Name:        hello.Name,
+			Addr:        conn.RemoteAddr().String(),
+			Threads:     hello.Threads,
+			ConnectedAt: time.Now(),
+		},
+	}
+	s.mu.Lock()
+	s.workers[w] = struct{}{}
+	if s.problem != nil {
+		s.send(w, Message{Type: TypeProblem, Problem: newProblem(*s.problem)})
+	}
+	s.mu.Unlock()
+	s.logger.Info("Worker connected", "worker", w.stats.Name, "addr", w.stats.Addr, "threads", w.stats.Threads)
+
+	go func() {
+		for message := range w.out {
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := encoder.Encode(message); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	err := s.read(ctx, w, reader, decoder)
+	s.mu.Lock()
+	delete(s.workers, w)
+	close(w.out)
+	s.mu.Unlock()
+	s.logger.Info("Worker disconnected", "worker", w.stats.Name, "addr", w.stats.Addr, "err", err)
+}
+
+func (s *Server) read(ctx context.Context, w *worker, reader *io.LimitedReader, decoder *json.Decoder) error {
+	for {
+		w.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		reader.N = maxMessageSize
+		var message Message
+		if err := decoder.Decode(&message); err != nil {
+			return err
+		}
+		switch message.Type {
+		case TypeStats:
+			s.mu.Lock()
+			w.stats.Threads = message.Threads
+			w.stats.Tries = message.Tries
+			if message.Hashrate != nil {
+				w.stats.Hashrate = *message.Hashrate
+			}
+			s.mu.Unlock()
+		case TypeSolution:
+			if message.Solution != nil {
+				s.acceptSolution(ctx, w, *message.Solution)
+			}
+		}
+	}
+}
+
+// acceptSolution checks solution against current problem, so invalid or
+// stale solutions of workers don't waste gas.
+func (s *Server) acceptSolution(ctx context.Context, w *worker, solution Solution) {
+	s.mu.Lock()
+	problem := s.problem
+	name := w.stats.Name
+	s.mu.Unlock()
+
+	if problem == nil || solution.Nonce == nil || solution.Nonce.Cmp(problem.Nonce) != 0 {
+		s.logger.Debug("Skipping stale solution", "worker", name, "nonce", solution.Nonce)
+		return
+	}
+	if solution.PrivateKeyB == nil || solution.PrivateKeyB.Sign() < 0 || solution.PrivateKeyB.BitLen() > 256 {
+		s.logger.Warn("Invalid solution", "worker", name, "nonce", solution.Nonce)
+		return
+	}
+	privateKeyA, err := utils.ParsePrivateKey(*problem.PrivateKeyA)
+	if err != nil {
+		return
+	}
+	privateKeyB, err := utils.ParsePrivateKey(*solution.PrivateKeyB)
+	if err != nil {
+		s.logger.Warn("Invalid solution", "worker", name, "nonce", solution.Nonce, "err", err)
+		return
+	}
+	if _, ok, err := solver.Verify(*privateKeyA, *privateKeyB, problem.Difficulty); err != nil || !ok {
+		s.logger.Warn("Invalid solution", "worker", name, "nonce", solution.Nonce, "err", err)
+		return
+	}
+
+	s.logger.Info("Got solution from worker", "worker", name, "nonce", solution.Nonce)
+	s.mu.Lock()
+	w.stats.Solutions++
+	s.mu.Unlock()
+	select {
+	case s.solutions <- solver.Solution{Nonce: *problem.Nonce, PrivateKeyA: *privateKeyA, PrivateKeyB: *privateKeyB}:
+	case <-ctx.Done():
+	}
+}
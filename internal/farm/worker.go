@@ -0,0 +1,216 @@
+package farm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/hashrate"
+	"infinity/miner/internal/solver"
+	"infinity/miner/internal/utils"
+	"log/slog"
+	"math/big"
+	"net"
+	"time"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// ErrRejected is returned by RunWorker when coordinator rejects worker, e.g. for invalid token.
+var ErrRejected = errors.New("coordinator rejected worker")
+
+type client struct {
+	addr       string
+	token      string
+	name       string
+	pool       *solver.Pool
+	solutionCh <-chan solver.Solution
+	logger     *slog.Logger
+
+	// owned by RunWorker goroutine
+	problem *PoW.PoWNewProblem
+	pending []Solution // found while disconnected
+}
+
+// RunWorker connects to coordinator at addr, solves its problems with solvers of pool
+// and sends back solutions, until ctx is canceled. Lost connection is restored with backoff,
+// solvers keep working on the last problem meanwhile.
+func RunWorker(ctx context.Context, addr string, token string, name string, pool *solver.Pool, solutionCh <-chan solver.Solution) error {
+	c := &client{
+		addr:       addr,
+		token:      token,
+		name:       name,
+		pool:       pool,
+		solutionCh: solutionCh,
+		logger:     slog.With("component", "worker"),
+	}
+	go pool.SampleEvery(ctx, hashrate.SampleInterval)
+
+	delay := minReconnectDelay
+	for {
+		connectedAt := time.Now()
+		err := c.session(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrRejected) {
+			return err
+		}
+		if time.Since(connectedAt) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+		c.logger.Warn("Lost coordinator connection, reconnecting", "addr", c.addr, "retry_in", delay, "err", err)
+
+		timer := time.NewTimer(delay)
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case solution := <-c.solutionCh:
+				c.keep(solution)
+			case <-timer.C:
+				waiting = false
+			}
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// keep remembers solution of the last problem until connection is restored.
+func (c *client) keep(solution solver.Solution) {
+	if c.problem == nil || solution.Nonce.Cmp(c.problem.Nonce) != 0 {
+		return
+	}
+	c.logger.Info("Solution found while disconnected, it will be sent after reconnect", "nonce", &solution.Nonce)
+	c.pending = append(c.pending, newSolution(solution))
+}
+
+func newSolution(solution solver.Solution) Solution {
+	return Solution{Nonce: new(big.Int).Set(&solution.Nonce), PrivateKeyB: solution.PrivateKeyB.D}
+}
+
+func (c *client) session(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+	encoder := json.NewEncoder(conn)
+	send := func(message Message) error {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return encoder.Encode(message)
+	}
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := send(Message{Type: TypeHello, Token: c.token, Name: c.name, Threads: len(c.pool.Solvers())}); err != nil {
+		return err
+	}
+	var welcome Message
+	if err := decoder.Decode(&welcome); err != nil {
+		return err
+	}
+	if welcome.Type != TypeWelcome {
+		return fmt.Errorf("%w: %s", ErrRejected, welcome.Error)
+	}
+	conn.SetDeadline(time.Time{})
+	c.logger.Info("Connected to coordinator", "addr", c.addr)
+
+	problems := make(chan PoW.PoWNewProblem)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- c.read(ctx, conn, decoder, problems)
+	}()
+
+	for _, solution := range c.pending {
+		if err := send(Message{Type: TypeSolution, Solution: &solution}); err != nil {
+			return err
+		}
+	}
+	c.pending = nil
+
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case problem := <-problems:
+			c.problem = &problem
+			c.logger.Info("Got new problem", "nonce", problem.Nonce, "block", problem.Raw.BlockNumber)
+			solver.Dispatch(c.pool.Solvers(), problem)
+		case solution := <-c.solutionCh:
+			if c.problem == nil || solution.Nonce.Cmp(c.problem.Nonce) != 0 {
+				continue
+			}
+			c.logger.Info("Solution found", "nonce", &solution.Nonce)
+			message := Message{Type: TypeSolution, Solution: new(Solution)}
+			*message.Solution = newSolution(solution)
+			if err := send(message); err != nil {
+				c.pending = append(c.pending, *message.Solution)
+				return err
+			}
+		case <-ticker.C:
+			if err := send(c.stats()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *client) read(ctx context.Context, conn net.Conn, decoder *json.Decoder, problems chan<- PoW.PoWNewProblem) error {
+	// problems can be minutes apart, so there is no read deadline, dead
+	// coordinator is detected by failing stats writes and tcp keep-alive
+	for {
+		var message Message
+		if err := decoder.Decode(&message); err != nil {
+			return err
+		}
+		if message.Type != TypeProblem || message.Problem == nil {
+			continue
+		}
+		problem, err := message.Problem.pow()
+		if err != nil {
+			c.logger.Warn("Invalid problem from coordinator", "err", err)
+			continue
+		}
+		select {
+		case problems <- problem:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *client) stats() Message {
+	var tries uint64
+	var rates hashrate.Rates
+	solvers := c.pool.Solvers()
+	for _, solver := range solvers {
+		tries += solver.NumTries.Load()
+		rates = rates.Add(solver.Hashrate.Rates())
+	}
+	c.logger.Info(
+		"Stats",
+		"threads", len(solvers),
+		"hashrate_10s", utils.FormatHashrate(rates.Rate10s),
+		"hashrate_1m", utils.FormatHashrate(rates.Rate1m),
+		"hashrate_15m", utils.FormatHashrate(rates.Rate15m),
+	)
+	return Message{Type: TypeStats, Threads: len(solvers), Tries: tries, Hashrate: &rates}
+}
@@ -0,0 +1,69 @@
+// Package hashrate measures rolling hashrate as exponential moving averages.
+package hashrate
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// SampleInterval is how often meters should be updated.
+const SampleInterval = time.Second
+
+var windows = [...]time.Duration{10 * time.Second, time.Minute, 15 * time.Minute}
+
+// Rates are hashrates in H/s averaged over 10 seconds, 1 minute and 15 minutes.
+type Rates struct {
+	Rate10s float64
+	Rate1m  float64
+	Rate15m float64
+}
+
+func (r Rates) Add(other Rates) Rates {
+	return Rates{
+		Rate10s: r.Rate10s + other.Rate10s,
+		Rate1m:  r.Rate1m + other.Rate1m,
+		Rate15m: r.Rate15m + other.Rate15m,
+	}
+}
+
+// Meter turns growing tries counter into rolling rates.
+type Meter struct {
+	mu     sync.Mutex
+	total  uint64
+	at     time.Time
+	primed bool
+	rates  [len(windows)]float64
+}
+
+// Update records counter value at now.
+func (m *Meter) Update(total uint64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.at.IsZero() {
+		m.total, m.at = total, now
+		return
+	}
+	elapsed := now.Sub(m.at)
+	if elapsed <= 0 || total < m.total {
+		return
+	}
+	rate := float64(total-m.total) / elapsed.Seconds()
+	for i, window := range windows {
+		if !m.primed {
+			m.rates[i] = rate
+			continue
+		}
+		alpha := 1 - math.Exp(-elapsed.Seconds()/window.Seconds())
+		m.rates[i] += alpha * (rate - m.rates[i])
+	}
+	m.primed = true
+	m.total, m.at = total, now
+}
+
+func (m *Meter) Rates() Rates {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Rates{Rate10s: m.rates[0], Rate1m: m.rates[1], Rate15m: m.rates[2]}
+}
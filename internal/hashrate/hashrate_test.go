@@ -0,0 +1,68 @@
+package hashrate
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMeter(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	type sample struct {
+		total uint64
+		at    time.Duration // since start
+	}
+	// ema of rate after 100 H/s over first second
+	ema := func(rate float64, elapsed, window time.Duration) float64 {
+		return 100 + (1-math.Exp(-elapsed.Seconds()/window.Seconds()))*(rate-100)
+	}
+
+	for _, test := range []struct {
+		name    string
+		samples []sample
+		want    Rates
+	}{
+		{"no samples", nil, Rates{}},
+		{"first sample is baseline", []sample{{1000, 0}}, Rates{}},
+		{"second sample primes rates", []sample{{1000, 0}, {1100, time.Second}}, Rates{100, 100, 100}},
+		{"steady rate", []sample{{0, 0}, {100, time.Second}, {200, 2 * time.Second}, {300, 3 * time.Second}}, Rates{100, 100, 100}},
+		{"rate change", []sample{{0, 0}, {100, time.Second}, {400, 2 * time.Second}}, Rates{
+			ema(300, time.Second, 10*time.Second),
+			ema(300, time.Second, time.Minute),
+			ema(300, time.Second, 15*time.Minute),
+		}},
+		{"longer interval", []sample{{0, 0}, {100, time.Second}, {100, 11 * time.Second}}, Rates{
+			ema(0, 10*time.Second, 10*time.Second),
+			ema(0, 10*time.Second, time.Minute),
+			ema(0, 10*time.Second, 15*time.Minute),
+		}},
+		{"counter reset and clock step back are ignored", []sample{{0, 0}, {100, time.Second}, {50, 2 * time.Second}, {200, 0}}, Rates{100, 100, 100}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var meter Meter
+			for _, sample := range test.samples {
+				meter.Update(sample.total, start.Add(sample.at))
+			}
+			got := meter.Rates()
+			for _, rate := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"10s", got.Rate10s, test.want.Rate10s},
+				{"1m", got.Rate1m, test.want.Rate1m},
+				{"15m", got.Rate15m, test.want.Rate15m},
+			} {
+				if math.Abs(rate.got-rate.want) > 1e-9 {
+					t.Errorf("%s: got %v, want %v", rate.name, rate.got, rate.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRatesAdd(t *testing.T) {
+	got := Rates{1, 2, 3}.Add(Rates{10, 20, 30})
+	if got != (Rates{11, 22, 33}) {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -0,0 +1,339 @@
+// Package history keeps problems, solutions and submission transactions
+// in bbolt database, so stats survive restarts.
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Transaction statuses
+const (
+	StatusLost        = "lost" // mined, but solution was not the first one
+	StatusWon         = "won"  // waiting for confirmations
+	StatusReverted    = "reverted"
+	StatusFailed      = "failed" // receipt was not received
+	StatusConfirmed   = "confirmed"
+	StatusUnconfirmed = "unconfirmed" // removed by reorg
+)
+
+// database is opened only for a single operation, otherwise its file lock
+// would keep history command from reading it while miner is running
+const lockTimeout = 5 * time.Second
+
+// writes queued for background writer, they are dropped when queue is full
+const queueSize = 256
+
+var (
+	problemsBucket     = []byte("problems")
+	solutionsBucket    = []byte("solutions")
+	transactionsBucket = []byte("transactions")
+)
+
+type Problem struct {
+	Nonce      *big.Int  `json:"nonce"`
+	Difficulty *big.Int  `json:"difficulty"`
+	Block      uint64    `json:"block"`
+	SeenAt     time.Time `json:"seen_at"`
+}
+
+type Solution struct {
+	Nonce     *big.Int       `json:"nonce"`
+	AddressAB common.Address `json:"address_ab"`
+	FoundAt   time.Time      `json:"found_at"`
+	SolveTime time.Duration  `json:"solve_time"` // since problem was seen, 0 if unknown
+}
+
+// Transaction is submission sent by miner. Amounts are in wei.
+type Transaction struct {
+	Hash         common.Hash `json:"hash"`
+	Nonce        *big.Int    `json:"nonce,omitempty"` // of problem
+	TxNonce      uint64      `json:"tx_nonce"`
+	GasPrice     *big.Int    `json:"gas_price,omitempty"`
+	Fee          *big.Int    `json:"fee,omitempty"` // nil until receipt
+	Status       string      `json:"status"`
+	RevertReason string      `json:"revert_reason,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	Reward       *big.Int    `json:"reward,omitempty"` // INFINITY, set when confirmed
+	Block        uint64      `json:"block,omitempty"`
+	SentAt       time.Time   `json:"sent_at,omitzero"` // zero if sent by previous run and found by backfill
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// Filter selects records. Zero values match everything.
+type Filter struct {
+	Since  time.Time
+	Until  time.Time
+	Nonce  *big.Int
+	Status string // transactions only
+	Limit  int    // last records
+}
+
+func (f Filter) match(at time.Time, nonce *big.Int) bool {
+	if !f.Since.IsZero() && at.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !at.Before(f.Until) {
+		return false
+	}
+	return f.Nonce == nil || (nonce != nil && nonce.Cmp(f.Nonce) == 0)
+}
+
+func last[T any](records []T, limit int) []T {
+	if limit > 0 && len(records) > limit {
+		return records[len(records)-limit:]
+	}
+	return records
+}
+
+// Store writes history to file at path from background writer, so mining
+// is not blocked by file lock. Nil Store drops all records.
+type Store struct {
+	path   string
+	logger *slog.Logger
+
+	queue chan func(tx *bolt.Tx) error
+	start sync.Once
+	done  chan struct{}
+}
+
+func Open(path string) *Store {
+	return &Store{
+		path:   path,
+		logger: slog.With("component", "history"),
+		queue:  make(chan func(tx *bolt.Tx) error, queueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// update queues write, writer is started by the first one.
+func (s *Store) update(fn func(tx *bolt.Tx) error) {
+	s.start.Do(func() {
+		go s.write()
+	})
+	select {
+	case s.queue <- fn:
+	default:
+		s.logger.Warn("History queue is full, dropping record")
+	}
+}
+
+// write applies queued writes, all pending ones in a single transaction.
+func (s *Store) write() {
+	defer close(s.done)
+	for fn := range s.queue {
+		batch := []func(tx *bolt.Tx) error{fn}
+		for pending := true; pending; {
+			select {
+			case fn, ok := <-s.queue:
+				if !ok {
+					pending = false
+					break
+				}
+				batch = append(batch, fn)
+			default:
+				pending = false
+			}
+		}
+		if err := s.apply(batch); err != nil {
+			s.logger.Warn("Cant write history", "records", len(batch), "err", err)
+		}
+	}
+}
+
+func (s *Store) apply(batch []func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, fn := range batch {
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close waits until queued records are written. Store can't be used after it.
+func (s *Store) Close() {
+	if s == nil {
+		return
+	}
+	s.start.Do(func() {
+		close(s.done)
+	})
+	close(s.queue)
+	<-s.done
+}
+
+// view calls fn with nil tx if database doesn't exist yet.
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	if _, err := os.Stat(s.path); errors.Is(err, fs.ErrNotExist) {
+		return fn(nil)
+	}
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+func put(tx *bolt.Tx, bucket []byte, key []byte, value any) error {
+	b, err := tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+func each[T any](tx *bolt.Tx, bucket []byte, fn func(T)) error {
+	if tx == nil {
+		return nil
+	}
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(_, data []byte) error {
+		var record T
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		fn(record)
+		return nil
+	})
+}
+
+// AddProblem stores problem, keyed by its nonce, so replayed problems are not duplicated.
+func (s *Store) AddProblem(problem Problem) {
+	if s == nil {
+		return
+	}
+	s.update(func(tx *bolt.Tx) error {
+		return put(tx, problemsBucket, common.BigToHash(problem.Nonce).Bytes(), problem)
+	})
+}
+
+func (s *Store) AddSolution(solution Solution) {
+	if s == nil {
+		return
+	}
+	s.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(solutionsBucket)
+		if err != nil {
+			return err
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return put(tx, solutionsBucket, binary.BigEndian.AppendUint64(nil, id), solution)
+	})
+}
+
+// UpdateTransaction calls fn with stored transaction, or a new one with given hash, and saves it.
+// fn is called later by writer.
+func (s *Store) UpdateTransaction(hash common.Hash, fn func(*Transaction)) {
+	if s == nil {
+		return
+	}
+	s.update(func(tx *bolt.Tx) error {
+		transaction := Transaction{Hash: hash}
+		if b := tx.Bucket(transactionsBucket); b != nil {
+			if data := b.Get(hash.Bytes()); data != nil {
+				if err := json.Unmarshal(data, &transaction); err != nil {
+					return err
+				}
+			}
+		}
+		fn(&transaction)
+		transaction.UpdatedAt = time.Now()
+		return put(tx, transactionsBucket, hash.Bytes(), transaction)
+	})
+}
+
+// Transaction returns stored transaction with hash, nil if there is no such one.
+func (s *Store) Transaction(hash common.Hash) (*Transaction, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var transaction *Transaction
+	err := s.view(func(tx *bolt.Tx) error {
+		if tx == nil {
+			return nil
+		}
+		b := tx.Bucket(transactionsBucket)
+		if b == nil {
+			return nil
+		}
+		data := b.Get(hash.Bytes())
+		if data == nil {
+			return nil
+		}
+		transaction = new(Transaction)
+		return json.Unmarshal(data, transaction)
+	})
+	return transaction, err
+}
+
+// Problems are ordered by nonce.
+func (s *Store) Problems(filter Filter) ([]Problem, error) {
+	var problems []Problem
+	err := s.view(func(tx *bolt.Tx) error {
+		return each(tx, problemsBucket, func(problem Problem) {
+			if filter.match(problem.SeenAt, problem.Nonce) {
+				problems = append(problems, problem)
+			}
+		})
+	})
+	return last(problems, filter.Limit), err
+}
+
+// Solutions are ordered by time they were found.
+func (s *Store) Solutions(filter Filter) ([]Solution, error) {
+	var solutions []Solution
+	err := s.view(func(tx *bolt.Tx) error {
+		return each(tx, solutionsBucket, func(solution Solution) {
+			if filter.match(solution.FoundAt, solution.Nonce) {
+				solutions = append(solutions, solution)
+			}
+		})
+	})
+	return last(solutions, filter.Limit), err
+}
+
+// Transactions are ordered by time they were sent.
+func (s *Store) Transactions(filter Filter) ([]Transaction, error) {
+	var transactions []Transaction
+	err := s.view(func(tx *bolt.Tx) error {
+		return each(tx, transactionsBucket, func(transaction Transaction) {
+			if filter.match(transaction.SentAt, transaction.Nonce) &&
+				(filter.Status == "" || filter.Status == transaction.Status) {
+				transactions = append(transactions, transaction)
+			}
+		})
+	})
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].SentAt.Before(transactions[j].SentAt)
+	})
+	return last(transactions, filter.Limit), err
+}
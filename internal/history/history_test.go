@@ -0,0 +1,71 @@
+package history
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestWritesAreQueued(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s := Open(path)
+
+	// history command holds the lock, writes must not wait for it
+	reader, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now()
+	s.AddProblem(Problem{Nonce: big.NewInt(1), Difficulty: big.NewInt(2), SeenAt: started})
+	s.AddSolution(Solution{Nonce: big.NewInt(1), FoundAt: started})
+	hash := common.HexToHash("0x01")
+	s.UpdateTransaction(hash, func(transaction *Transaction) {
+		transaction.Status = StatusWon
+		transaction.SentAt = started
+	})
+	s.UpdateTransaction(hash, func(transaction *Transaction) {
+		transaction.Status = StatusConfirmed
+		transaction.Reward = big.NewInt(3)
+	})
+	if elapsed := time.Since(started); elapsed > time.Second/2 {
+		t.Fatalf("writes took %s", elapsed)
+	}
+	time.Sleep(100 * time.Millisecond)
+	reader.Close()
+	s.Close()
+
+	problems, err := s.Problems(Filter{})
+	if err != nil || len(problems) != 1 || problems[0].Nonce.Int64() != 1 {
+		t.Fatalf("got %v, %v", problems, err)
+	}
+	solutions, err := s.Solutions(Filter{})
+	if err != nil || len(solutions) != 1 {
+		t.Fatalf("got %v, %v", solutions, err)
+	}
+	transaction, err := s.Transaction(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction == nil || transaction.Status != StatusConfirmed || transaction.Reward.Int64() != 3 || !transaction.SentAt.Equal(started) {
+		t.Fatalf("got %+v", transaction)
+	}
+	if missing, err := s.Transaction(common.HexToHash("0x02")); missing != nil || err != nil {
+		t.Fatalf("got %+v, %v for missing transaction", missing, err)
+	}
+}
+
+func TestCloseWithoutWrites(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "history.db"))
+	s.Close()
+	if transactions, err := s.Transactions(Filter{}); err != nil || len(transactions) != 0 {
+		t.Fatalf("got %v, %v", transactions, err)
+	}
+
+	var empty *Store
+	empty.AddProblem(Problem{})
+	empty.Close()
+}
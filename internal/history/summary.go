@@ -0,0 +1,74 @@
+package history
+
+import (
+	"math/big"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Summary is totals of all records matching filter, amounts are in wei.
+type Summary struct {
+	Problems     uint64
+	Solutions    uint64
+	Transactions uint64
+	Statuses     map[string]uint64
+	Fees         *big.Int // $S
+	Rewards      *big.Int // INFINITY
+	AvgSolveTime time.Duration
+	FirstSeen    time.Time // of first problem
+}
+
+func (s *Store) Summary(filter Filter) (*Summary, error) {
+	summary := &Summary{
+		Statuses: make(map[string]uint64),
+		Fees:     new(big.Int),
+		Rewards:  new(big.Int),
+	}
+	var solveTime time.Duration
+	var solved int64
+	err := s.view(func(tx *bolt.Tx) error {
+		err := each(tx, problemsBucket, func(problem Problem) {
+			if !filter.match(problem.SeenAt, problem.Nonce) {
+				return
+			}
+			summary.Problems++
+			if summary.FirstSeen.IsZero() || problem.SeenAt.Before(summary.FirstSeen) {
+				summary.FirstSeen = problem.SeenAt
+			}
+		})
+		if err != nil {
+			return err
+		}
+		err = each(tx, solutionsBucket, func(solution Solution) {
+			if !filter.match(solution.FoundAt, solution.Nonce) {
+				return
+			}
+			summary.Solutions++
+			if solution.SolveTime > 0 {
+				solveTime += solution.SolveTime
+				solved++
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return each(tx, transactionsBucket, func(transaction Transaction) {
+			if !filter.match(transaction.SentAt, transaction.Nonce) {
+				return
+			}
+			summary.Transactions++
+			summary.Statuses[transaction.Status]++
+			if transaction.Fee != nil {
+				summary.Fees.Add(summary.Fees, transaction.Fee)
+			}
+			if transaction.Reward != nil && transaction.Status == StatusConfirmed {
+				summary.Rewards.Add(summary.Rewards, transaction.Reward)
+			}
+		})
+	})
+	if solved > 0 {
+		summary.AvgSolveTime = solveTime / time.Duration(solved)
+	}
+	return summary, err
+}
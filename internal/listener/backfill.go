@@ -0,0 +1,156 @@
+package listener
+
+import (
+	"context"
+	"infinity/miner/internal"
+	"infinity/miner/internal/contracts/PoW"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// max blocks per eth_getLogs request, public nodes limit range
+const logsChunkSize = uint64(2_000)
+
+type BackfillStats struct {
+	FromBlock      uint64
+	ToBlock        uint64
+	NumProblems    uint64
+	NumSubmissions uint64
+	OwnSubmissions []OwnSubmission
+	OwnRewards     *big.Int
+}
+
+type OwnSubmission struct {
+	*PoW.PoWSubmission
+	MinedAt time.Time // block timestamp
+}
+
+// BlockTime returns timestamp of block.
+func BlockTime(ctx context.Context, conn *ethclient.Client, blockNumber uint64) (time.Time, error) {
+	header, err := conn.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// ScanLogs calls fn for every PoW contract log in [fromBlock, toBlock] with one of topics,
+// requesting logs in chunks. After each chunk onChunk is called with its last block.
+func ScanLogs(
+	ctx context.Context,
+	conn *ethclient.Client,
+	fromBlock uint64,
+	toBlock uint64,
+	topics []common.Hash,
+	fn func(types.Log) error,
+	onChunk func(uint64) error,
+) error {
+	powAddress := common.HexToAddress(internal.PoWAddress)
+	for from := fromBlock; from <= toBlock; from += logsChunkSize {
+		to := min(from+logsChunkSize-1, toBlock)
+		logs, err := conn.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{powAddress},
+			Topics:    [][]common.Hash{topics},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, log := range logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+		if onChunk != nil {
+			if err := onChunk(to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var powABI, _ = PoW.PoWMetaData.ParseABI()
+
+func EventID(name string) common.Hash {
+	return powABI.Events[name].ID
+}
+
+// finalBlock returns the last block with confirmations blocks on top of it
+// at head, see submitter.CheckConfirmations.
+func finalBlock(head uint64, confirmations uint64) uint64 {
+	if confirmations <= 1 {
+		return head
+	}
+	if head+1 < confirmations {
+		return 0
+	}
+	return head + 1 - confirmations
+}
+
+// Backfill replays NewProblem and Submission logs since persisted cursor
+// up to the last block with confirmations and moves cursor there, so only
+// final submissions are reported. On the first run it only saves cursor.
+func Backfill(ctx context.Context, conn *ethclient.Client, miner common.Address, cursorFile string, confirmations uint64) (*BackfillStats, error) {
+	latest, err := conn.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head := finalBlock(latest, confirmations)
+
+	cursor, ok, err := LoadCursor(cursorFile)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &BackfillStats{FromBlock: cursor + 1, ToBlock: head, OwnRewards: new(big.Int)}
+	if !ok {
+		stats.FromBlock = head + 1
+		return stats, SaveCursor(cursorFile, head)
+	}
+
+	pow := PoW.NewPoW()
+	newProblemID := EventID(PoW.PoWNewProblemEventName)
+	submissionID := EventID(PoW.PoWSubmissionEventName)
+
+	err = ScanLogs(
+		ctx,
+		conn,
+		stats.FromBlock,
+		stats.ToBlock,
+		[]common.Hash{newProblemID, submissionID},
+		func(log types.Log) error {
+			switch log.Topics[0] {
+			case newProblemID:
+				stats.NumProblems++
+			case submissionID:
+				submission, err := pow.UnpackSubmissionEvent(&log)
+				if err != nil {
+					return err
+				}
+				stats.NumSubmissions++
+				if submission.Miner == miner {
+					// reward belongs to the day of its block, not of backfill
+					minedAt, err := BlockTime(ctx, conn, log.BlockNumber)
+					if err != nil {
+						return err
+					}
+					stats.OwnSubmissions = append(stats.OwnSubmissions, OwnSubmission{submission, minedAt})
+					stats.OwnRewards.Add(stats.OwnRewards, submission.Reward)
+				}
+			}
+			return nil
+		},
+		func(blockNumber uint64) error {
+			return SaveCursor(cursorFile, blockNumber)
+		},
+	)
+	return stats, err
+}
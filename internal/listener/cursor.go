@@ -0,0 +1,40 @@
+package listener
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadCursor returns last processed block and false, if there is no cursor yet.
+// Empty path disables cursor.
+func LoadCursor(path string) (uint64, bool, error) {
+	if path == "" {
+		return 0, false, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	blockNumber, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return blockNumber, true, nil
+}
+
+func SaveCursor(path string, blockNumber uint64) error {
+	if path == "" {
+		return nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(blockNumber, 10)+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
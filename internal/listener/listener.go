@@ -1,51 +1,193 @@
 package listener
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"infinity/miner/internal"
 	"infinity/miner/internal/contracts/PoW"
-	"log"
-	"os"
+	"log/slog"
+	"math/big"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
 )
 
-func SubscribeToProblems() (chan PoW.PoWNewProblem, error) {
-	WS := os.Getenv("INFINITY_WS")
-	if WS == "" {
-		return nil, errors.New("set INFINITY_WS variable")
-	}
-
-	conn, err := ethclient.Dial(WS)
+// CurrentProblem reads canonical problem from the contract at the latest block.
+func CurrentProblem(ctx context.Context, conn *ethclient.Client) (*PoW.PoWNewProblem, error) {
+	blockNumber, err := conn.BlockNumber(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	pow := PoW.NewPoW()
 	instance := pow.Instance(conn, common.HexToAddress(internal.PoWAddress))
-	logs, sub, err := instance.WatchLogs(nil, "NewProblem")
+	currentProblem, err := bind.Call(
+		instance,
+		&bind.CallOpts{BlockNumber: new(big.Int).SetUint64(blockNumber), Context: ctx},
+		pow.PackCurrentProblem(),
+		pow.UnpackCurrentProblem,
+	)
 	if err != nil {
 		return nil, err
 	}
 
+	return &PoW.PoWNewProblem{
+		Nonce:       currentProblem.Arg0,
+		PrivateKeyA: currentProblem.Arg1,
+		Difficulty:  currentProblem.Arg2,
+		Raw:         &types.Log{BlockNumber: blockNumber},
+	}, nil
+}
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+func subscribe(ctx context.Context, WS string) (*ethclient.Client, chan types.Log, event.Subscription, error) {
+	conn, err := ethclient.DialContext(ctx, WS)
+	if err != nil {
+		return nil, nil, nil, internal.NewDialError(WS, err)
+	}
+
+	instance := PoW.NewPoW().Instance(conn, common.HexToAddress(internal.PoWAddress))
+	logs, sub, err := instance.WatchLogs(&bind.WatchOpts{Context: ctx}, "NewProblem")
+	if err != nil {
+		conn.Close()
+		return nil, nil, nil, err
+	}
+	return conn, logs, sub, nil
+}
+
+// SubscribeToProblems streams NewProblem events until ctx is canceled.
+// Lost subscription is restored with backoff, state changes are sent to
+// the returned channel: error when subscription is lost, nil when restored.
+// Cursor is kept confirmations blocks behind problems, see Backfill.
+func SubscribeToProblems(ctx context.Context, WS string, cursorFile string, confirmations uint64) (chan PoW.PoWNewProblem, <-chan error, error) {
+	conn, logs, sub, err := subscribe(ctx, WS)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	problems := make(chan PoW.PoWNewProblem)
+	states := make(chan error)
+	l := &listener{
+		pow:           PoW.NewPoW(),
+		problems:      problems,
+		cursorFile:    cursorFile,
+		confirmations: confirmations,
+		logger:        slog.With("component", "listener"),
+	}
 
 	go func() {
 		for {
-			select {
-			case err := <-sub.Err():
-				log.Fatal("Subscription error:", err)
-			case newPorblemLog := <-logs:
-				newProblem, err := pow.UnpackNewProblemEvent(&newPorblemLog)
-				if err != nil {
-					log.Println("Parse error:", err)
-					continue
-				}
-				problems <- *newProblem
+			err := l.follow(ctx, conn, logs, sub)
+			sub.Unsubscribe()
+			conn.Close()
+			if ctx.Err() != nil {
+				return
 			}
+			l.logger.Warn("Subscription lost, reconnecting", "err", err)
+			if !send(ctx, states, err) {
+				return
+			}
+
+			conn, logs, sub = l.reconnect(ctx, WS)
+			if conn == nil {
+				return
+			}
+			l.logger.Info("Subscription restored")
+			if !send(ctx, states, nil) {
+				conn.Close()
+				return
+			}
+			// problems could be published while disconnected
+			l.sendCurrentProblem(ctx, conn)
 		}
 	}()
 
-	return problems, nil
+	return problems, states, nil
+}
+
+type listener struct {
+	pow           *PoW.PoW
+	problems      chan<- PoW.PoWNewProblem
+	cursorFile    string
+	confirmations uint64
+	logger        *slog.Logger
+}
+
+// follow forwards problems until subscription fails or ctx is canceled.
+func (l *listener) follow(ctx context.Context, conn *ethclient.Client, logs <-chan types.Log, sub event.Subscription) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			return fmt.Errorf("subscription error: %w", err)
+		case newPorblemLog := <-logs:
+			newProblem, err := l.pow.UnpackNewProblemEvent(&newPorblemLog)
+			if err != nil {
+				l.logger.Warn("Cant parse log", "block", newPorblemLog.BlockNumber, "err", &internal.ParseError{What: "NewProblem log", Err: err})
+				continue
+			}
+			if !send(ctx, l.problems, *newProblem) {
+				return ctx.Err()
+			}
+
+			if !newPorblemLog.Removed {
+				// submission of this problem can still be unconfirmed,
+				// so its block must be replayed after restart
+				cursor := finalBlock(newPorblemLog.BlockNumber, l.confirmations)
+				if err := SaveCursor(l.cursorFile, cursor); err != nil {
+					l.logger.Warn("Cant save cursor", "block", cursor, "err", err)
+				}
+				continue
+			}
+			// problem was reorged out, follow it with canonical one
+			l.logger.Info("Problem was removed by reorg", "nonce", newProblem.Nonce, "block", newPorblemLog.BlockNumber)
+			l.sendCurrentProblem(ctx, conn)
+		}
+	}
+}
+
+func (l *listener) sendCurrentProblem(ctx context.Context, conn *ethclient.Client) {
+	currentProblem, err := CurrentProblem(ctx, conn)
+	if err != nil {
+		l.logger.Warn("Cant get current problem", "err", err)
+		return
+	}
+	send(ctx, l.problems, *currentProblem)
+}
+
+// reconnect subscribes again until it succeeds or ctx is canceled.
+func (l *listener) reconnect(ctx context.Context, WS string) (*ethclient.Client, chan types.Log, event.Subscription) {
+	delay := minReconnectDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil, nil
+		case <-time.After(delay):
+		}
+
+		conn, logs, sub, err := subscribe(ctx, WS)
+		if err == nil {
+			return conn, logs, sub
+		}
+		delay = min(delay*2, maxReconnectDelay)
+		l.logger.Warn("Cant restore subscription", "retry_in", delay, "err", err)
+	}
+}
+
+func send[T any](ctx context.Context, ch chan<- T, value T) bool {
+	select {
+	case ch <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
@@ -0,0 +1,31 @@
+// Package logging configures process wide slog logger. Components take their
+// loggers with slog.With("component", name), so they must be created after Setup.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Setup makes text or json handler with given level default for slog and log packages.
+func Setup(w io.Writer, level string, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("log_level is %q, expected debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("log_format is %q, expected text or json", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
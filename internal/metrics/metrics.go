@@ -0,0 +1,141 @@
+// Package metrics exports miner telemetry in Prometheus text format.
+package metrics
+
+import (
+	"errors"
+	"infinity/miner/internal/hashrate"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "infinity"
+
+type SolverStats struct {
+	Tries     uint64
+	Solutions uint64
+	Hashrate  hashrate.Rates
+}
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	Problems          prometheus.Counter
+	ProblemLatency    prometheus.Histogram
+	Submissions       *prometheus.CounterVec // by status and reason
+	GasSpent          prometheus.Counter
+	Balance           prometheus.Gauge
+	ListenerConnected prometheus.Gauge
+}
+
+// New creates metrics with own registry, solvers is called on every scrape.
+func New(solvers func() []SolverStats) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		Problems: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "problems_total",
+			Help:      "Problems received.",
+		}),
+		ProblemLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "problem_latency_seconds",
+			Help:      "Delay between block with new problem and its delivery to solvers.",
+			Buckets:   []float64{0.25, 0.5, 1, 2, 5, 10, 30, 60},
+		}),
+		Submissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "submissions_total",
+			Help:      "Submissions by status (sent, confirmed, reverted, unconfirmed, failed, skipped) and reason.",
+		}, []string{"status", "reason"}),
+		GasSpent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_spent_sonic_total",
+			Help:      "Gas spent on submissions, in $S.",
+		}),
+		Balance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "balance_sonic",
+			Help:      "Submitter account balance, in $S.",
+		}),
+		ListenerConnected: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "listener_connected",
+			Help:      "1 if problem subscription is active.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.Problems,
+		m.ProblemLatency,
+		m.Submissions,
+		m.GasSpent,
+		m.Balance,
+		m.ListenerConnected,
+		newSolverCollector(solvers),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve starts /metrics endpoint on addr, returned func stops it.
+func (m *Metrics) Serve(addr string) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("Metrics server stopped", "component", "metrics", "err", err)
+		}
+	}()
+	return func() { server.Close() }, nil
+}
+
+type solverCollector struct {
+	solvers   func() []SolverStats
+	tries     *prometheus.Desc
+	solutions *prometheus.Desc
+	hashrate  *prometheus.Desc
+}
+
+func newSolverCollector(solvers func() []SolverStats) *solverCollector {
+	labels := []string{"solver"}
+	return &solverCollector{
+		solvers:   solvers,
+		tries:     prometheus.NewDesc(namespace+"_solver_tries_total", "Keys tried by solver.", labels, nil),
+		solutions: prometheus.NewDesc(namespace+"_solver_solutions_total", "Solutions found by solver.", labels, nil),
+		hashrate:  prometheus.NewDesc(namespace+"_solver_hashrate", "Solver hashrate averaged over window, H/s.", append(labels, "window"), nil),
+	}
+}
+
+func (c *solverCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tries
+	ch <- c.solutions
+	ch <- c.hashrate
+}
+
+func (c *solverCollector) Collect(ch chan<- prometheus.Metric) {
+	for i, solver := range c.solvers() {
+		id := strconv.Itoa(i)
+		ch <- prometheus.MustNewConstMetric(c.tries, prometheus.CounterValue, float64(solver.Tries), id)
+		ch <- prometheus.MustNewConstMetric(c.solutions, prometheus.CounterValue, float64(solver.Solutions), id)
+		ch <- prometheus.MustNewConstMetric(c.hashrate, prometheus.GaugeValue, solver.Hashrate.Rate10s, id, "10s")
+		ch <- prometheus.MustNewConstMetric(c.hashrate, prometheus.GaugeValue, solver.Hashrate.Rate1m, id, "1m")
+		ch <- prometheus.MustNewConstMetric(c.hashrate, prometheus.GaugeValue, solver.Hashrate.Rate15m, id, "15m")
+	}
+}
@@ -0,0 +1,204 @@
+// Package notify sends miner events to HTTP webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	SolutionFound        = "solution_found"
+	SubmissionConfirmed  = "submission_confirmed"
+	SubmissionReverted   = "submission_reverted"
+	BalanceLow           = "balance_low"
+	ListenerDisconnected = "listener_disconnected"
+	ContractPaused       = "contract_paused"
+	Test                 = "test"
+)
+
+var Events = []string{
+	SolutionFound,
+	SubmissionConfirmed,
+	SubmissionReverted,
+	BalanceLow,
+	ListenerDisconnected,
+	ContractPaused,
+}
+
+const (
+	queueSize      = 64
+	requestTimeout = 10 * time.Second
+	attempts       = 3
+)
+
+type Event struct {
+	Type    string            `json:"type"`
+	Message string            `json:"message"`
+	Miner   string            `json:"miner,omitempty"` // submitter address
+	Time    time.Time         `json:"time"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// Notifier posts events to webhook from background worker.
+// Nil Notifier drops all events.
+type Notifier struct {
+	url    string
+	body   *template.Template // nil - JSON of event
+	events map[string]bool
+	client *http.Client
+	logger *slog.Logger
+
+	queue chan Event
+	start sync.Once
+	done  chan struct{}
+}
+
+// New creates notifier for url. body is Go template of request body executed
+// with Event, empty - JSON of event. events is comma separated list of event
+// types to send, empty - all.
+func New(url string, body string, events string) (*Notifier, error) {
+	n := &Notifier{
+		url:    url,
+		events: make(map[string]bool),
+		client: &http.Client{Timeout: requestTimeout},
+		logger: slog.With("component", "notify"),
+		queue:  make(chan Event, queueSize),
+		done:   make(chan struct{}),
+	}
+
+	if body != "" {
+		tmpl, err := template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("webhook_template: %w", err)
+		}
+		n.body = tmpl
+	}
+
+	n.events[Test] = true
+	for _, event := range strings.Split(events, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		if !slices.Contains(Events, event) {
+			return nil, fmt.Errorf("webhook_events: unknown event %q, expected %s", event, strings.Join(Events, ", "))
+		}
+		n.events[event] = true
+	}
+	if len(n.events) == 1 {
+		for _, event := range Events {
+			n.events[event] = true
+		}
+	}
+	return n, nil
+}
+
+func toJSON(value any) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// Start runs background worker, it stops after Close.
+func (n *Notifier) Start() {
+	if n == nil {
+		return
+	}
+	n.start.Do(func() {
+		go func() {
+			defer close(n.done)
+			for event := range n.queue {
+				if err := n.Send(context.Background(), event); err != nil {
+					n.logger.Warn("Cant send notification", "type", event.Type, "err", err)
+				}
+			}
+		}()
+	})
+}
+
+// Notify queues event, it is dropped if queue is full.
+func (n *Notifier) Notify(event Event) {
+	if n == nil || !n.events[event.Type] {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case n.queue <- event:
+	default:
+		n.logger.Warn("Notification queue is full, dropping event", "type", event.Type)
+	}
+}
+
+// Close stops accepting events and waits until queued ones are sent or ctx is done.
+func (n *Notifier) Close(ctx context.Context) {
+	if n == nil {
+		return
+	}
+	n.Start()
+	close(n.queue)
+	select {
+	case <-n.done:
+	case <-ctx.Done():
+	}
+}
+
+// Send posts event synchronously, retrying failed requests.
+func (n *Notifier) Send(ctx context.Context, event Event) error {
+	if n == nil || !n.events[event.Type] {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	var body bytes.Buffer
+	if n.body != nil {
+		if err := n.body.Execute(&body, event); err != nil {
+			return fmt.Errorf("webhook_template: %w", err)
+		}
+	} else if err := json.NewEncoder(&body).Encode(event); err != nil {
+		return err
+	}
+
+	var err error
+	for attempt := range attempts {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = n.post(ctx, body.Bytes()); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (n *Notifier) post(ctx context.Context, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", response.Status)
+	}
+	return nil
+}
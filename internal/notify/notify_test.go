@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhook records bodies of requests, the first failures requests are answered with 500.
+type webhook struct {
+	mu       sync.Mutex
+	failures int
+	requests int
+	bodies   []string
+}
+
+func (w *webhook) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	body, _ := io.ReadAll(request.Body)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.requests++
+	if w.requests <= w.failures {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.bodies = append(w.bodies, string(body))
+}
+
+func (w *webhook) received() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.bodies...)
+}
+
+func (w *webhook) numRequests() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.requests
+}
+
+func newWebhook(t *testing.T, failures int) (*webhook, string) {
+	t.Helper()
+	w := &webhook{failures: failures}
+	server := httptest.NewServer(w)
+	t.Cleanup(server.Close)
+	return w, server.URL
+}
+
+var testTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestSendJSON(t *testing.T) {
+	w, url := newWebhook(t, 0)
+	n, err := New(url, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Send(context.Background(), Event{
+		Type:    SolutionFound,
+		Message: "Solution found",
+		Time:    testTime,
+		Data:    map[string]string{"nonce": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bodies := w.received()
+	if len(bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(bodies))
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(bodies[0]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != SolutionFound || event.Message != "Solution found" || !event.Time.Equal(testTime) || event.Data["nonce"] != "1" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestSendTemplate(t *testing.T) {
+	w, url := newWebhook(t, 0)
+	n, err := New(url, `{"text": {{json .Message}}, "nonce": "{{index .Data "nonce"}}"}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Send(context.Background(), Event{Type: SolutionFound, Message: `Solution "found"`, Data: map[string]string{"nonce": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"text": "Solution \"found\"", "nonce": "1"}`
+	if bodies := w.received(); len(bodies) != 1 || bodies[0] != want {
+		t.Fatalf("got %q, want %q", bodies, want)
+	}
+}
+
+func TestEventsFilter(t *testing.T) {
+	if _, err := New("http://localhost", "", "solution_found,unknown"); err == nil {
+		t.Fatal("unknown event is accepted")
+	}
+
+	w, url := newWebhook(t, 0)
+	n, err := New(url, "", "solution_found, balance_low")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range []string{SolutionFound, SubmissionConfirmed, BalanceLow, ContractPaused, Test} {
+		if err := n.Send(context.Background(), Event{Type: event}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bodies := w.received()
+	var got []string
+	for _, body := range bodies {
+		var event Event
+		if err := json.Unmarshal([]byte(body), &event); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, event.Type)
+	}
+	want := []string{SolutionFound, BalanceLow, Test}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	w, url := newWebhook(t, 1)
+	n, err := New(url, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(context.Background(), Event{Type: Test}); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.received()) != 1 || w.numRequests() != 2 {
+		t.Fatalf("got %d requests and %d delivered, want 2 and 1", w.numRequests(), len(w.received()))
+	}
+
+	w, url = newWebhook(t, attempts)
+	n, err = New(url, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(context.Background(), Event{Type: Test}); err == nil {
+		t.Fatal("got no error after all attempts failed")
+	}
+	if w.numRequests() != attempts {
+		t.Fatalf("got %d requests, want %d", w.numRequests(), attempts)
+	}
+}
+
+func TestCloseDrainsQueue(t *testing.T) {
+	w, url := newWebhook(t, 0)
+	n, err := New(url, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// queued before worker is started
+	for range 3 {
+		n.Notify(Event{Type: SolutionFound})
+	}
+	n.Start()
+	for range 2 {
+		n.Notify(Event{Type: BalanceLow})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	n.Close(ctx)
+
+	if bodies := w.received(); len(bodies) != 5 {
+		t.Fatalf("got %d requests, want 5", len(bodies))
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Start()
+	n.Notify(Event{Type: Test})
+	if err := n.Send(context.Background(), Event{Type: Test}); err != nil {
+		t.Fatal(err)
+	}
+	n.Close(context.Background())
+}
@@ -0,0 +1,124 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"infinity/miner/internal"
+	"infinity/miner/internal/contracts/PoW"
+	"log/slog"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	ErrWrongChain = errors.New("wrong network")
+	ErrNoContract = errors.New("no contract code")
+	ErrPaused     = errors.New("contract is paused")
+)
+
+type ContractState struct {
+	ChainID                 *big.Int
+	Paused                  bool
+	Reward                  *big.Int
+	Difficulty              *big.Int
+	NumSubmissions          *big.Int
+	Owner                   common.Address
+	Infinity                common.Address
+	UpgradeInterfaceVersion string
+}
+
+func ReadContractState(ctx context.Context, conn *ethclient.Client) (*ContractState, error) {
+	powAddress := common.HexToAddress(internal.PoWAddress)
+
+	chainID, err := conn.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if chainID.Cmp(big.NewInt(internal.ChainID)) != 0 {
+		return nil, fmt.Errorf("%w: chain id %s, expected %d", ErrWrongChain, chainID, internal.ChainID)
+	}
+
+	code, err := conn.CodeAt(ctx, powAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("%w at %s", ErrNoContract, powAddress)
+	}
+
+	pow := PoW.NewPoW()
+	instance := pow.Instance(conn, powAddress)
+	opts := &bind.CallOpts{Context: ctx}
+	state := &ContractState{ChainID: chainID}
+
+	if state.Paused, err = bind.Call(instance, opts, pow.PackPaused(), pow.UnpackPaused); err != nil {
+		return nil, fmt.Errorf("paused: %w", err)
+	}
+	if state.Reward, err = bind.Call(instance, opts, pow.PackReward(), pow.UnpackReward); err != nil {
+		return nil, fmt.Errorf("reward: %w", err)
+	}
+	if state.Difficulty, err = bind.Call(instance, opts, pow.PackDifficulty(), pow.UnpackDifficulty); err != nil {
+		return nil, fmt.Errorf("difficulty: %w", err)
+	}
+	if state.NumSubmissions, err = bind.Call(instance, opts, pow.PackNumSubmissions(), pow.UnpackNumSubmissions); err != nil {
+		return nil, fmt.Errorf("numSubmissions: %w", err)
+	}
+	if state.Owner, err = bind.Call(instance, opts, pow.PackOwner(), pow.UnpackOwner); err != nil {
+		return nil, fmt.Errorf("owner: %w", err)
+	}
+	if state.Infinity, err = bind.Call(instance, opts, pow.PackINFINITY(), pow.UnpackINFINITY); err != nil {
+		return nil, fmt.Errorf("INFINITY: %w", err)
+	}
+	if state.UpgradeInterfaceVersion, err = bind.Call(instance, opts, pow.PackUPGRADEINTERFACEVERSION(), pow.UnpackUPGRADEINTERFACEVERSION); err != nil {
+		return nil, fmt.Errorf("UPGRADE_INTERFACE_VERSION: %w", err)
+	}
+
+	return state, nil
+}
+
+func (s *ContractState) Print(printf func(string, ...any)) {
+	printf("Chain id: %s", s.ChainID)
+	printf("PoW contract: %s (owner %s, version %s)", internal.PoWAddress, s.Owner, s.UpgradeInterfaceVersion)
+	printf("INFINITY token: %s", s.Infinity)
+	printf("Paused: %t", s.Paused)
+	printf("Reward: %f INFINITY", new(big.Float).Quo(new(big.Float).SetInt(s.Reward), big.NewFloat(params.Ether)))
+	printf("Difficulty: %s", common.BigToAddress(s.Difficulty))
+	printf("Num submissions: %s", s.NumSubmissions)
+}
+
+func (s *ContractState) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("chain_id", s.ChainID.String()),
+		slog.String("contract", internal.PoWAddress),
+		slog.String("owner", s.Owner.Hex()),
+		slog.String("version", s.UpgradeInterfaceVersion),
+		slog.String("token", s.Infinity.Hex()),
+		slog.Bool("paused", s.Paused),
+		slog.String("reward", new(big.Float).Quo(new(big.Float).SetInt(s.Reward), big.NewFloat(params.Ether)).Text('f', 6)),
+		slog.String("difficulty", common.BigToAddress(s.Difficulty).Hex()),
+		slog.String("num_submissions", s.NumSubmissions.String()),
+	)
+}
+
+// Paused reads paused flag of the contract.
+func Paused(ctx context.Context, conn *ethclient.Client) (bool, error) {
+	pow := PoW.NewPoW()
+	instance := pow.Instance(conn, common.HexToAddress(internal.PoWAddress))
+	return bind.Call(instance, &bind.CallOpts{Context: ctx}, pow.PackPaused(), pow.UnpackPaused)
+}
+
+func Check(ctx context.Context, conn *ethclient.Client) (*ContractState, error) {
+	state, err := ReadContractState(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if state.Paused {
+		return state, fmt.Errorf("%w: %s", ErrPaused, internal.PoWAddress)
+	}
+	return state, nil
+}
@@ -0,0 +1,84 @@
+package solutionlog
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+var ErrNoKey = errors.New("record is encrypted, but key is not given")
+
+// Decrypter opens records written by Log.
+type Decrypter struct {
+	passphrase string
+	publicKey  *[keySize]byte
+	privateKey *[keySize]byte
+
+	keys map[string]*[keySize]byte // derived from passphrase, by salt
+}
+
+// NewDecrypter creates decrypter with passphrase and/or hex private key, both are optional.
+func NewDecrypter(passphrase string, privateKey string) (*Decrypter, error) {
+	d := &Decrypter{passphrase: passphrase, keys: make(map[string]*[keySize]byte)}
+	if privateKey != "" {
+		key, err := ParseKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		public, err := curve25519.X25519(key[:], curve25519.Basepoint)
+		if err != nil {
+			return nil, err
+		}
+		d.privateKey = key
+		d.publicKey = (*[keySize]byte)(public)
+	}
+	return d, nil
+}
+
+// Decrypt returns plain record of line, plain lines are returned as is.
+func (d *Decrypter) Decrypt(line string) (string, error) {
+	if encoded, ok := strings.CutPrefix(line, scryptPrefix); ok {
+		if d.passphrase == "" {
+			return "", ErrNoKey
+		}
+		sealed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(sealed) < saltSize+nonceSize {
+			return "", errors.New("malformed record")
+		}
+		salt, nonce, sealed := sealed[:saltSize], (*[nonceSize]byte)(sealed[saltSize:saltSize+nonceSize]), sealed[saltSize+nonceSize:]
+		key, ok := d.keys[string(salt)]
+		if !ok {
+			key, err = deriveKey(d.passphrase, salt)
+			if err != nil {
+				return "", err
+			}
+			d.keys[string(salt)] = key
+		}
+		record, ok := secretbox.Open(nil, sealed, nonce, key)
+		if !ok {
+			return "", errors.New("wrong passphrase")
+		}
+		return string(record), nil
+	}
+
+	if encoded, ok := strings.CutPrefix(line, boxPrefix); ok {
+		if d.privateKey == nil {
+			return "", ErrNoKey
+		}
+		sealed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", errors.New("malformed record")
+		}
+		record, ok := box.OpenAnonymous(nil, sealed, d.publicKey, d.privateKey)
+		if !ok {
+			return "", errors.New("wrong private key")
+		}
+		return string(record), nil
+	}
+
+	return line, nil
+}
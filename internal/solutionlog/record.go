@@ -0,0 +1,71 @@
+package solutionlog
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"infinity/miner/internal/utils"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Record is a found solution. Records of older versions have only address and key AB.
+type Record struct {
+	AddressAB    common.Address
+	PrivateKeyAB *ecdsa.PrivateKey
+	Nonce        *big.Int // of problem
+	PrivateKeyB  *ecdsa.PrivateKey
+}
+
+// String formats record as "addressAB:privateKeyAB:nonce:privateKeyB", keys in hex.
+func (r Record) String() string {
+	line := fmt.Sprintf("%s:%s", r.AddressAB, r.PrivateKeyAB.D.Text(16))
+	if r.Nonce != nil && r.PrivateKeyB != nil {
+		line += fmt.Sprintf(":%s:%s", r.Nonce, r.PrivateKeyB.D.Text(16))
+	}
+	return line
+}
+
+// ParseRecord parses plain record, see Decrypter for encrypted ones.
+func ParseRecord(line string) (*Record, error) {
+	fields := strings.Split(line, ":")
+	if len(fields) != 2 && len(fields) != 4 {
+		return nil, errors.New("malformed record")
+	}
+	if !common.IsHexAddress(fields[0]) {
+		return nil, errors.New("invalid address AB")
+	}
+	record := &Record{AddressAB: common.HexToAddress(fields[0])}
+
+	var err error
+	if record.PrivateKeyAB, err = parseKey(fields[1]); err != nil {
+		return nil, fmt.Errorf("invalid private key AB: %w", err)
+	}
+	if crypto.PubkeyToAddress(record.PrivateKeyAB.PublicKey) != record.AddressAB {
+		return nil, errors.New("private key AB doesn't match address AB")
+	}
+	if len(fields) == 2 {
+		return record, nil
+	}
+
+	nonce, ok := new(big.Int).SetString(fields[2], 10)
+	if !ok {
+		return nil, errors.New("invalid problem nonce")
+	}
+	record.Nonce = nonce
+	if record.PrivateKeyB, err = parseKey(fields[3]); err != nil {
+		return nil, fmt.Errorf("invalid private key B: %w", err)
+	}
+	return record, nil
+}
+
+func parseKey(value string) (*ecdsa.PrivateKey, error) {
+	raw, ok := new(big.Int).SetString(value, 16)
+	if !ok || raw.BitLen() > 256 {
+		return nil, errors.New("expected 256 bit hex number")
+	}
+	return utils.ParsePrivateKey(*raw)
+}
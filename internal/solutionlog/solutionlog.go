@@ -0,0 +1,136 @@
+// Package solutionlog appends found solutions (private keys AB and B with
+// problem nonce) to a file, in plain text or encrypted with passphrase (scrypt + NaCl secretbox)
+// or NaCl box public key.
+//
+// Every line is a Record, plain records are written as is, encrypted ones
+// as "scrypt:<base64>" or "box:<base64>".
+package solutionlog
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	scryptPrefix = "scrypt:"
+	boxPrefix    = "box:"
+
+	saltSize  = 16
+	nonceSize = 24
+	keySize   = 32
+)
+
+// Log is safe for concurrent use. Nil Log drops all records.
+type Log struct {
+	path string
+	seal func(record []byte) (string, error)
+
+	mu sync.Mutex
+}
+
+// Open creates log at path. At most one of passphrase and publicKey (hex) can be set,
+// without them records are written in plain text.
+func Open(path string, passphrase string, publicKey string) (*Log, error) {
+	l := &Log{path: path}
+	switch {
+	case passphrase != "" && publicKey != "":
+		return nil, errors.New("solution log can be encrypted either with passphrase or with public key")
+	case passphrase != "":
+		// key is derived once per run, salt is stored in every record
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		key, err := deriveKey(passphrase, salt)
+		if err != nil {
+			return nil, err
+		}
+		l.seal = func(record []byte) (string, error) {
+			var nonce [nonceSize]byte
+			if _, err := rand.Read(nonce[:]); err != nil {
+				return "", err
+			}
+			sealed := append(append(append([]byte(nil), salt...), nonce[:]...), secretbox.Seal(nil, record, &nonce, key)...)
+			return scryptPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+		}
+	case publicKey != "":
+		key, err := ParseKey(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("public key: %w", err)
+		}
+		l.seal = func(record []byte) (string, error) {
+			sealed, err := box.SealAnonymous(nil, record, key, rand.Reader)
+			if err != nil {
+				return "", err
+			}
+			return boxPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+		}
+	default:
+		l.seal = func(record []byte) (string, error) {
+			return string(record), nil
+		}
+	}
+	return l, nil
+}
+
+// Write appends record and syncs file, so the key is on disk before solution is submitted.
+func (l *Log) Write(record Record) error {
+	if l == nil {
+		return nil
+	}
+	line, err := l.seal([]byte(record.String()))
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func deriveKey(passphrase string, salt []byte) (*[keySize]byte, error) {
+	raw, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+	return (*[keySize]byte)(raw), nil
+}
+
+// ParseKey parses hex NaCl box key.
+func ParseKey(value string) (*[keySize]byte, error) {
+	raw, err := hex.DecodeString(value)
+	if err != nil || len(raw) != keySize {
+		return nil, fmt.Errorf("must be %d hex symbols", 2*keySize)
+	}
+	return (*[keySize]byte)(raw), nil
+}
+
+// GenerateKey returns hex NaCl box key pair for Open and Decrypter.
+func GenerateKey() (publicKey string, privateKey string, err error) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(public[:]), hex.EncodeToString(private[:]), nil
+}
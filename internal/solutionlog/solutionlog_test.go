@@ -0,0 +1,207 @@
+package solutionlog
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newRecord(t *testing.T, nonce int64) Record {
+	t.Helper()
+	keyAB, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Record{
+		AddressAB:    crypto.PubkeyToAddress(keyAB.PublicKey),
+		PrivateKeyAB: keyAB,
+		Nonce:        big.NewInt(nonce),
+		PrivateKeyB:  keyB,
+	}
+}
+
+// writeLog writes records with log and returns lines of the file.
+func writeLog(t *testing.T, l *Log, path string, records []Record) []string {
+	t.Helper()
+	for _, record := range records {
+		if err := l.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+}
+
+func checkRecord(t *testing.T, line string, want Record) {
+	t.Helper()
+	record, err := ParseRecord(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.AddressAB != want.AddressAB ||
+		record.PrivateKeyAB.D.Cmp(want.PrivateKeyAB.D) != 0 ||
+		record.Nonce.Cmp(want.Nonce) != 0 ||
+		record.PrivateKeyB.D.Cmp(want.PrivateKeyB.D) != 0 {
+		t.Fatalf("got %s, want %s", record, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	publicKey, privateKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name       string
+		passphrase string
+		publicKey  string
+		privateKey string
+		prefix     string
+	}{
+		{name: "plain"},
+		{name: "passphrase", passphrase: "secret", prefix: scryptPrefix},
+		{name: "box", publicKey: publicKey, privateKey: privateKey, prefix: boxPrefix},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "solution.log")
+			l, err := Open(path, test.passphrase, test.publicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			records := []Record{newRecord(t, 1), newRecord(t, 2)}
+			lines := writeLog(t, l, path, records)
+			if len(lines) != len(records) {
+				t.Fatalf("got %d lines, want %d", len(lines), len(records))
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Fatalf("got mode %v, want 0600", info.Mode().Perm())
+			}
+
+			decrypter, err := NewDecrypter(test.passphrase, test.privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, line := range lines {
+				if !strings.HasPrefix(line, test.prefix) {
+					t.Fatalf("line %q has no prefix %q", line, test.prefix)
+				}
+				if test.prefix != "" && strings.Contains(line, records[i].PrivateKeyAB.D.Text(16)) {
+					t.Fatal("private key is written in plain text")
+				}
+				plain, err := decrypter.Decrypt(line)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkRecord(t, plain, records[i])
+			}
+		})
+	}
+}
+
+func TestOpenKeys(t *testing.T) {
+	publicKey, _, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open("solution.log", "secret", publicKey); err == nil {
+		t.Fatal("both passphrase and public key are accepted")
+	}
+	if _, err := Open("solution.log", "", "abcd"); err == nil {
+		t.Fatal("short public key is accepted")
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	dir := t.TempDir()
+	publicKey, _, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPrivateKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scryptPath := filepath.Join(dir, "scrypt.log")
+	l, err := Open(scryptPath, "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scryptLine := writeLog(t, l, scryptPath, []Record{newRecord(t, 1)})[0]
+
+	boxPath := filepath.Join(dir, "box.log")
+	l, err = Open(boxPath, "", publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boxLine := writeLog(t, l, boxPath, []Record{newRecord(t, 1)})[0]
+
+	noKey, err := NewDecrypter("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{scryptLine, boxLine} {
+		if _, err := noKey.Decrypt(line); !errors.Is(err, ErrNoKey) {
+			t.Fatalf("got %v, want ErrNoKey", err)
+		}
+	}
+
+	wrongKey, err := NewDecrypter("wrong", otherPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{scryptLine, boxLine, scryptPrefix + "!", boxPrefix + "!"} {
+		if _, err := wrongKey.Decrypt(line); err == nil || errors.Is(err, ErrNoKey) {
+			t.Fatalf("got %v for %q, want decryption error", err, line)
+		}
+	}
+
+	if _, err := NewDecrypter("", "abcd"); err == nil {
+		t.Fatal("short private key is accepted")
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	record := newRecord(t, 1)
+	legacy := Record{AddressAB: record.AddressAB, PrivateKeyAB: record.PrivateKeyAB}
+	parsed, err := ParseRecord(legacy.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.AddressAB != record.AddressAB || parsed.PrivateKeyAB.D.Cmp(record.PrivateKeyAB.D) != 0 || parsed.Nonce != nil || parsed.PrivateKeyB != nil {
+		t.Fatalf("got %s, want %s", parsed, legacy)
+	}
+
+	other := newRecord(t, 1)
+	for _, line := range []string{
+		"",
+		record.AddressAB.Hex(),
+		record.String() + ":1",
+		"0x1234:" + record.PrivateKeyAB.D.Text(16),
+		record.AddressAB.Hex() + ":xyz",
+		record.AddressAB.Hex() + ":1" + strings.Repeat("0", 64),
+		record.AddressAB.Hex() + ":" + other.PrivateKeyAB.D.Text(16), // key of other address
+		record.AddressAB.Hex() + ":" + record.PrivateKeyAB.D.Text(16) + ":x:" + record.PrivateKeyB.D.Text(16),
+		record.AddressAB.Hex() + ":" + record.PrivateKeyAB.D.Text(16) + ":1:xyz",
+	} {
+		if _, err := ParseRecord(line); err == nil {
+			t.Fatalf("record %q is accepted", line)
+		}
+	}
+}
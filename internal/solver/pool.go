@@ -0,0 +1,132 @@
+package solver
+
+import (
+	"context"
+	"infinity/miner/internal/affinity"
+	"infinity/miner/internal/contracts/PoW"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Pool runs solvers and can change their number at runtime.
+type Pool struct {
+	ctx          context.Context
+	reserveCores int
+	pin          bool
+	solutionCh   chan<- Solution
+	logger       *slog.Logger
+
+	mu      sync.Mutex
+	solvers []*Solver
+	cancels []context.CancelFunc
+	cpus    []int
+}
+
+func NewPool(ctx context.Context, reserveCores int, pin bool, solutionCh chan<- Solution) *Pool {
+	return &Pool{
+		ctx:          ctx,
+		reserveCores: reserveCores,
+		pin:          pin,
+		solutionCh:   solutionCh,
+		logger:       slog.With("component", "solver"),
+	}
+}
+
+// Resize starts or stops solvers, so threads (one per available cpu if 0) are running.
+// Running solvers keep their cpus. Returns started solvers.
+func (p *Pool) Resize(threads int) ([]*Solver, error) {
+	plan, err := affinity.Plan(threads, p.reserveCores)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := len(plan); i < len(p.solvers); i++ {
+		p.cancels[i]()
+	}
+	if len(plan) < len(p.solvers) {
+		p.solvers = p.solvers[:len(plan)]
+		p.cancels = p.cancels[:len(plan)]
+	}
+
+	var started []*Solver
+	for i := len(p.solvers); i < len(plan); i++ {
+		solver := NewSolver()
+		ctx, cancel := context.WithCancel(p.ctx)
+		cpu := plan[i]
+		go func() {
+			if p.pin {
+				if err := affinity.Pin(cpu); err != nil {
+					p.logger.Warn("Cant pin solver", "solver", i, "cpu", cpu, "err", err)
+				}
+			}
+			solver.Solve(ctx, p.solutionCh)
+		}()
+		p.solvers = append(p.solvers, solver)
+		p.cancels = append(p.cancels, cancel)
+		started = append(started, solver)
+	}
+	p.cpus = plan
+	return started, nil
+}
+
+func (p *Pool) Solvers() []*Solver {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Solver(nil), p.solvers...)
+}
+
+// Sample updates hashrate meters of running solvers.
+func (p *Pool) Sample(now time.Time) {
+	for _, solver := range p.Solvers() {
+		solver.Hashrate.Update(solver.NumTries.Load(), now)
+	}
+}
+
+// SampleEvery calls Sample every interval until ctx is canceled.
+func (p *Pool) SampleEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	p.Sample(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.Sample(now)
+		}
+	}
+}
+
+func (p *Pool) CPUs() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]int(nil), p.cpus...)
+}
+
+// Dispatch sends problem to solvers without waiting for them.
+func Dispatch(solvers []*Solver, problem PoW.PoWNewProblem) {
+	for _, solver := range solvers {
+		go func() {
+			select {
+			case solver.ProblemCh <- problem:
+			case <-solver.Done():
+			}
+		}()
+	}
+}
+
+// Start runs threads solvers (one per available cpu if 0) leaving reserveCores
+// cpus free, optionally pinning every solver to its cpu. Returns solvers and their cpus.
+func Start(ctx context.Context, threads int, reserveCores int, pin bool, solutionCh chan<- Solution) ([]*Solver, []int, error) {
+	pool := NewPool(ctx, reserveCores, pin, solutionCh)
+	solvers, err := pool.Resize(threads)
+	if err != nil {
+		return nil, nil, err
+	}
+	return solvers, pool.CPUs(), nil
+}
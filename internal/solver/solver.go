@@ -1,12 +1,13 @@
 package solver
 
 import (
+	"context"
 	"crypto/ecdsa"
-	"fmt"
 	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/hashrate"
 	"infinity/miner/internal/utils"
 	"math/big"
-	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -25,25 +26,37 @@ type Solution struct {
 
 type Solver struct {
 	ProblemCh    chan PoW.PoWNewProblem
-	NumTries     uint64
-	NumSolutions uint64
+	NumTries     atomic.Uint64
+	NumSolutions atomic.Uint64
+	Hashrate     hashrate.Meter
+
+	paused atomic.Bool
+	done   chan struct{}
 }
 
 func NewSolver() *Solver {
 	problemCh := make(chan PoW.PoWNewProblem)
 	return &Solver{
 		ProblemCh: problemCh,
+		done:      make(chan struct{}),
 	}
 }
 
-func logSolution(addressAB common.Address, privateKeyAB *ecdsa.PrivateKey) {
-	f, err := os.OpenFile("solution.log", os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
-	if err != nil {
-		return
-	}
-	defer f.Close()
+// Done is closed when Solve returns.
+func (s *Solver) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Solver) Pause() {
+	s.paused.Store(true)
+}
 
-	_, _ = f.WriteString(fmt.Sprintf("%s:%s\n", addressAB, privateKeyAB.D.Text(16)))
+func (s *Solver) Resume() {
+	s.paused.Store(false)
+}
+
+func (s *Solver) IsPaused() bool {
+	return s.paused.Load()
 }
 
 func trySolve(privateKeyA ecdsa.PrivateKey, difficulty big.Int) (*ecdsa.PrivateKey, error) {
@@ -58,40 +71,66 @@ func trySolve(privateKeyA ecdsa.PrivateKey, difficulty big.Int) (*ecdsa.PrivateK
 	}
 	addressAB := crypto.PubkeyToAddress(privateKeyAB.PublicKey)
 
-	result := new(big.Int)
-	result.Xor(magic, addressAB.Big())
-	if result.Cmp(&difficulty) < 0 {
-		go logSolution(addressAB, privateKeyAB)
+	if IsSolution(addressAB, &difficulty) {
 		return privateKeyB, nil
 	}
 
 	return nil, nil
 }
 
-func (s *Solver) Solve(solutionCh chan<- Solution) {
+func IsSolution(addressAB common.Address, difficulty *big.Int) bool {
+	result := new(big.Int)
+	result.Xor(magic, addressAB.Big())
+	return result.Cmp(difficulty) < 0
+}
+
+// Verify checks if privateKeyB solves problem with privateKeyA and difficulty.
+func Verify(privateKeyA ecdsa.PrivateKey, privateKeyB ecdsa.PrivateKey, difficulty *big.Int) (*ecdsa.PrivateKey, bool, error) {
+	privateKeyAB, err := utils.EcAdd(privateKeyA, privateKeyB)
+	if err != nil {
+		return nil, false, err
+	}
+	addressAB := crypto.PubkeyToAddress(privateKeyAB.PublicKey)
+	return privateKeyAB, IsSolution(addressAB, difficulty), nil
+}
+
+func (s *Solver) Solve(ctx context.Context, solutionCh chan<- Solution) {
+	defer close(s.done)
 	var nonce big.Int
 	var privateKeyA *ecdsa.PrivateKey
 	var difficulty big.Int
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case problem := <-s.ProblemCh:
 			nonce = *problem.Nonce
-			privateKeyA, _ = utils.ParsePrivateKey(*problem.PrivateKeyA)
+			parsed, err := utils.ParsePrivateKey(*problem.PrivateKeyA)
+			if err != nil {
+				// unsolvable problem, wait for the next one
+				privateKeyA = nil
+				continue
+			}
+			privateKeyA = parsed
 			difficulty = *problem.Difficulty
 		default:
-			if privateKeyA == nil {
+			if privateKeyA == nil || s.paused.Load() {
 				time.Sleep(time.Second / 10)
 				continue
 			}
 
 			privateKeyB, _ := trySolve(*privateKeyA, difficulty)
-			s.NumTries++
+			s.NumTries.Add(1)
 			if privateKeyB != nil {
-				s.NumSolutions++
-				solutionCh <- Solution{
+				s.NumSolutions.Add(1)
+				select {
+				case solutionCh <- Solution{
 					Nonce:       nonce,
 					PrivateKeyA: *privateKeyA,
 					PrivateKeyB: *privateKeyB,
+				}:
+				case <-ctx.Done():
+					return
 				}
 			}
 		}
@@ -0,0 +1,173 @@
+package stats
+
+import (
+	"context"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/listener"
+	"math/big"
+	"sort"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type MinerStats struct {
+	Miner       common.Address
+	Wins        uint64
+	Rewards     *big.Int
+	Tags        map[string]uint64
+	TimeToSolve time.Duration // sum, use AvgTimeToSolve
+	NumTimed    uint64
+}
+
+func (m *MinerStats) AvgTimeToSolve() time.Duration {
+	if m.NumTimed == 0 {
+		return 0
+	}
+	return m.TimeToSolve / time.Duration(m.NumTimed)
+}
+
+type NetworkStats struct {
+	FromBlock   uint64
+	ToBlock     uint64
+	Problems    uint64
+	Submissions uint64
+	Rewards     *big.Int
+	TimeToSolve time.Duration
+	NumTimed    uint64
+	Miners      []*MinerStats // sorted by wins
+}
+
+func (n *NetworkStats) AvgTimeToSolve() time.Duration {
+	if n.NumTimed == 0 {
+		return 0
+	}
+	return n.TimeToSolve / time.Duration(n.NumTimed)
+}
+
+// DecodeTag returns client tag from Submission data, like "cpu-go".
+func DecodeTag(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if !utf8.Valid(data) {
+		return hexutil.Encode(data)
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return hexutil.Encode(data)
+		}
+	}
+	return string(data)
+}
+
+type blockTimes struct {
+	ctx   context.Context
+	conn  *ethclient.Client
+	cache map[uint64]time.Time
+}
+
+func (b *blockTimes) get(blockNumber uint64) (time.Time, error) {
+	if t, ok := b.cache[blockNumber]; ok {
+		return t, nil
+	}
+	header, err := b.conn.HeaderByNumber(b.ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return time.Time{}, err
+	}
+	t := time.Unix(int64(header.Time), 0)
+	b.cache[blockNumber] = t
+	return t, nil
+}
+
+// Network scans Submission and NewProblem logs in [fromBlock, toBlock]
+// and aggregates them per miner.
+func Network(ctx context.Context, conn *ethclient.Client, fromBlock uint64, toBlock uint64) (*NetworkStats, error) {
+	pow := PoW.NewPoW()
+	newProblemID := listener.EventID(PoW.PoWNewProblemEventName)
+	submissionID := listener.EventID(PoW.PoWSubmissionEventName)
+
+	stats := &NetworkStats{FromBlock: fromBlock, ToBlock: toBlock, Rewards: new(big.Int)}
+	miners := make(map[common.Address]*MinerStats)
+	times := &blockTimes{ctx: ctx, conn: conn, cache: make(map[uint64]time.Time)}
+
+	// block of the problem being solved, unknown until first NewProblem in range.
+	// Winning submission emits next NewProblem in the same transaction,
+	// so previous one is kept in case it comes first.
+	var problemBlock, prevProblemBlock *uint64
+	var problemTx common.Hash
+
+	err := listener.ScanLogs(
+		ctx,
+		conn,
+		fromBlock,
+		toBlock,
+		[]common.Hash{newProblemID, submissionID},
+		func(log types.Log) error {
+			if log.Topics[0] == newProblemID {
+				stats.Problems++
+				blockNumber := log.BlockNumber
+				prevProblemBlock, problemBlock, problemTx = problemBlock, &blockNumber, log.TxHash
+				return nil
+			}
+
+			submission, err := pow.UnpackSubmissionEvent(&log)
+			if err != nil {
+				return err
+			}
+			stats.Submissions++
+			stats.Rewards.Add(stats.Rewards, submission.Reward)
+
+			miner, ok := miners[submission.Miner]
+			if !ok {
+				miner = &MinerStats{
+					Miner:   submission.Miner,
+					Rewards: new(big.Int),
+					Tags:    make(map[string]uint64),
+				}
+				miners[submission.Miner] = miner
+			}
+			miner.Wins++
+			miner.Rewards.Add(miner.Rewards, submission.Reward)
+			miner.Tags[DecodeTag(submission.Data)]++
+
+			solvedBlock := problemBlock
+			if problemTx == log.TxHash {
+				solvedBlock = prevProblemBlock
+			}
+			if solvedBlock == nil {
+				return nil
+			}
+			problemTime, err := times.get(*solvedBlock)
+			if err != nil {
+				return err
+			}
+			solveTime, err := times.get(log.BlockNumber)
+			if err != nil {
+				return err
+			}
+			miner.TimeToSolve += solveTime.Sub(problemTime)
+			miner.NumTimed++
+			stats.TimeToSolve += solveTime.Sub(problemTime)
+			stats.NumTimed++
+			return nil
+		},
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, miner := range miners {
+		stats.Miners = append(stats.Miners, miner)
+	}
+	sort.Slice(stats.Miners, func(i, j int) bool {
+		return stats.Miners[i].Wins > stats.Miners[j].Wins
+	})
+	return stats, nil
+}
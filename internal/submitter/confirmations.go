@@ -0,0 +1,141 @@
+package submitter
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type SubmissionStatus int
+
+const (
+	SubmissionPending SubmissionStatus = iota
+	SubmissionConfirmed
+	SubmissionUnconfirmed // removed by reorg
+)
+
+func (s SubmissionStatus) String() string {
+	switch s {
+	case SubmissionPending:
+		return "pending"
+	case SubmissionConfirmed:
+		return "confirmed"
+	case SubmissionUnconfirmed:
+		return "unconfirmed"
+	}
+	return "unknown"
+}
+
+// Submission is our winning submission, reward is final only after
+// confirmationDepth blocks on top of it.
+type Submission struct {
+	TxHash      common.Hash
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Reward      *big.Int
+	Status      SubmissionStatus
+}
+
+// blocks after which reorged submission is forgotten
+const maxUnconfirmedAge = uint64(256)
+
+type confirmations struct {
+	mu      sync.Mutex
+	depth   uint64
+	pending []*Submission
+}
+
+func (c *confirmations) add(submission *Submission) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, submission)
+}
+
+func (s *Submitter) findSubmission(receipt *types.Receipt) *Submission {
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil
+	}
+	for _, log := range receipt.Logs {
+		if log.Address != s.powAddress {
+			continue
+		}
+		submission, err := s.pow.UnpackSubmissionEvent(log)
+		if err != nil || submission.Miner != s.Address {
+			continue
+		}
+		return &Submission{
+			TxHash:      receipt.TxHash,
+			BlockNumber: receipt.BlockNumber.Uint64(),
+			BlockHash:   receipt.BlockHash,
+			Reward:      submission.Reward,
+			Status:      SubmissionPending,
+		}
+	}
+	return nil
+}
+
+// CheckConfirmations re-reads receipts of pending submissions and returns ones,
+// that became final and ones, that were removed by reorg since last check.
+func (s *Submitter) CheckConfirmations(ctx context.Context) ([]*Submission, []*Submission, error) {
+	s.confirmations.mu.Lock()
+	defer s.confirmations.mu.Unlock()
+
+	if len(s.confirmations.pending) == 0 {
+		return nil, nil, nil
+	}
+
+	head, err := s.conn.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var confirmed, reorged []*Submission
+	var errs []error
+	pending := s.confirmations.pending[:0]
+	for _, submission := range s.confirmations.pending {
+		receipt, err := s.conn.TransactionReceipt(ctx, submission.TxHash)
+		// only missing receipt means reorg, on other errors (timeouts, rate limits)
+		// state is unknown, so submission stays as it is
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			errs = append(errs, err)
+			pending = append(pending, submission)
+			continue
+		}
+		var canonical *Submission
+		if err == nil {
+			canonical = s.findSubmission(receipt)
+		}
+
+		if canonical == nil {
+			if submission.Status != SubmissionUnconfirmed {
+				submission.Status = SubmissionUnconfirmed
+				reorged = append(reorged, submission)
+			}
+			if head < submission.BlockNumber+maxUnconfirmedAge {
+				pending = append(pending, submission)
+			}
+			continue
+		}
+
+		// transaction could be re-included in another block
+		submission.BlockNumber = canonical.BlockNumber
+		submission.BlockHash = canonical.BlockHash
+		submission.Reward = canonical.Reward
+		submission.Status = SubmissionPending
+
+		if head+1 >= submission.BlockNumber+s.confirmations.depth {
+			submission.Status = SubmissionConfirmed
+			confirmed = append(confirmed, submission)
+			continue
+		}
+		pending = append(pending, submission)
+	}
+	s.confirmations.pending = pending
+
+	return confirmed, reorged, errors.Join(errs...)
+}
@@ -0,0 +1,180 @@
+package submitter
+
+import (
+	"context"
+	"encoding/json"
+	"infinity/miner/internal/contracts/PoW"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// node answers eth_blockNumber with head and eth_getTransactionReceipt with
+// receipt, null if receipt is nil, or with error if failing.
+type node struct {
+	head    uint64
+	receipt *types.Receipt
+	failing bool
+}
+
+func (n *node) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	var call struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(request.Body).Decode(&call); err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reply := map[string]any{"jsonrpc": "2.0", "id": call.ID}
+	switch {
+	case call.Method == "eth_blockNumber":
+		reply["result"] = hexUint(n.head)
+	case call.Method == "eth_getTransactionReceipt" && n.failing:
+		reply["error"] = map[string]any{"code": -32005, "message": "rate limit exceeded"}
+	case call.Method == "eth_getTransactionReceipt":
+		reply["result"] = n.receipt
+	default:
+		reply["error"] = map[string]any{"code": -32601, "message": "method not found"}
+	}
+	response.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(response).Encode(reply)
+}
+
+func hexUint(n uint64) string {
+	return "0x" + new(big.Int).SetUint64(n).Text(16)
+}
+
+// winningReceipt is receipt of successful submit by miner in block.
+func winningReceipt(t *testing.T, s *Submitter, txHash common.Hash, block uint64, reward *big.Int) *types.Receipt {
+	t.Helper()
+	parsed, err := PoW.PoWMetaData.ParseABI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := parsed.Events[PoW.PoWSubmissionEventName]
+	data, err := event.Inputs.NonIndexed().Pack(reward, []byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash := common.BigToHash(new(big.Int).SetUint64(block))
+	log := &types.Log{
+		Address:     s.powAddress,
+		Topics:      []common.Hash{event.ID, common.BytesToHash(s.Address.Bytes()), {}},
+		Data:        data,
+		BlockNumber: block,
+		TxHash:      txHash,
+		BlockHash:   blockHash,
+	}
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		Logs:        []*types.Log{log},
+		TxHash:      txHash,
+		BlockHash:   blockHash,
+		BlockNumber: new(big.Int).SetUint64(block),
+	}
+}
+
+func TestCheckConfirmations(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+	reward := big.NewInt(5)
+
+	for _, test := range []struct {
+		name      string
+		head      uint64
+		block     uint64 // of canonical receipt, 0 - receipt not found
+		failing   bool
+		confirmed int
+		reorged   int
+		pending   int
+		status    SubmissionStatus
+		err       bool
+	}{
+		{name: "below depth", head: 11, block: 10, pending: 1, status: SubmissionPending},
+		{name: "reaches depth", head: 12, block: 10, confirmed: 1, status: SubmissionConfirmed},
+		{name: "re-included deeper", head: 12, block: 11, pending: 1, status: SubmissionPending},
+		{name: "reorged", head: 12, reorged: 1, pending: 1, status: SubmissionUnconfirmed},
+		{name: "reorged long ago", head: 10 + maxUnconfirmedAge, reorged: 1, status: SubmissionUnconfirmed},
+		{name: "transient error", head: 12, failing: true, pending: 1, status: SubmissionPending, err: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			n := &node{head: test.head, failing: test.failing}
+			server := httptest.NewServer(n)
+			defer server.Close()
+			conn, err := ethclient.Dial(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			s := &Submitter{
+				Address:       common.HexToAddress("0xaa"),
+				conn:          *conn,
+				powAddress:    common.HexToAddress("0xbb"),
+				pow:           *PoW.NewPoW(),
+				confirmations: &confirmations{depth: 3},
+			}
+			if test.block != 0 {
+				n.receipt = winningReceipt(t, s, txHash, test.block, reward)
+			}
+			submission := &Submission{TxHash: txHash, BlockNumber: 10, Reward: reward, Status: SubmissionPending}
+			s.confirmations.add(submission)
+
+			confirmed, reorged, err := s.CheckConfirmations(context.Background())
+			if (err != nil) != test.err {
+				t.Fatalf("got error %v, want error %v", err, test.err)
+			}
+			if len(confirmed) != test.confirmed || len(reorged) != test.reorged || len(s.confirmations.pending) != test.pending {
+				t.Fatalf("got %d confirmed, %d reorged, %d pending, want %d, %d, %d",
+					len(confirmed), len(reorged), len(s.confirmations.pending), test.confirmed, test.reorged, test.pending)
+			}
+			if submission.Status != test.status {
+				t.Fatalf("got status %s, want %s", submission.Status, test.status)
+			}
+			if test.block != 0 && (submission.BlockNumber != test.block || submission.Reward.Cmp(reward) != 0) {
+				t.Fatalf("got block %d, reward %v, want %d, %v", submission.BlockNumber, submission.Reward, test.block, reward)
+			}
+		})
+	}
+}
+
+func TestCheckConfirmationsReportsReorgOnce(t *testing.T) {
+	n := &node{head: 12}
+	server := httptest.NewServer(n)
+	defer server.Close()
+	conn, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s := &Submitter{
+		Address:       common.HexToAddress("0xaa"),
+		conn:          *conn,
+		powAddress:    common.HexToAddress("0xbb"),
+		pow:           *PoW.NewPoW(),
+		confirmations: &confirmations{depth: 3},
+	}
+	txHash := common.HexToHash("0x01")
+	s.confirmations.add(&Submission{TxHash: txHash, BlockNumber: 10, Reward: big.NewInt(5)})
+
+	if _, reorged, err := s.CheckConfirmations(context.Background()); err != nil || len(reorged) != 1 {
+		t.Fatalf("got %d reorged, %v, want 1", len(reorged), err)
+	}
+	if _, reorged, err := s.CheckConfirmations(context.Background()); err != nil || len(reorged) != 0 {
+		t.Fatalf("got %d reorged, %v, want reorg reported once", len(reorged), err)
+	}
+
+	// re-included in canonical chain
+	n.head = 14
+	n.receipt = winningReceipt(t, s, txHash, 12, big.NewInt(5))
+	confirmed, _, err := s.CheckConfirmations(context.Background())
+	if err != nil || len(confirmed) != 1 || confirmed[0].BlockNumber != 12 {
+		t.Fatalf("got %v, %v, want re-included submission confirmed", confirmed, err)
+	}
+}
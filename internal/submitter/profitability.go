@@ -0,0 +1,139 @@
+package submitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/config"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+)
+
+var ErrUnprofitable = errors.New("submission is unprofitable")
+
+type GuardMode string
+
+const (
+	GuardOff   GuardMode = "off"
+	GuardSkip  GuardMode = "skip"  // don't send unprofitable submissions
+	GuardPause GuardMode = "pause" // also pause mining until it is profitable again
+)
+
+// profitGuard keeps reward and gas of submission, refreshed by CheckProfitability,
+// so submission is not delayed by extra calls.
+type profitGuard struct {
+	mode      GuardMode
+	price     *big.Float // 1 INFINITY in $S
+	minProfit *big.Float // in $S
+
+	mu     sync.Mutex
+	reward *big.Int // nil until the first check
+	winGas uint64   // max gas used by winning submission, 0 if unknown
+}
+
+func newProfitGuard(cfg *config.Config) *profitGuard {
+	return &profitGuard{
+		mode:      GuardMode(cfg.ProfitGuard),
+		price:     cfg.Price,
+		minProfit: cfg.MinProfit,
+	}
+}
+
+// gas of the next submission, losing and reverted ones use less gas than
+// winning one, so they are not counted.
+func (g *profitGuard) gas() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.winGas == 0 {
+		return gasLimit
+	}
+	return g.winGas
+}
+
+func (g *profitGuard) observeWin(gasUsed uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.winGas = max(g.winGas, gasUsed)
+}
+
+type Profitability struct {
+	Reward *big.Int   // in INFINITY wei
+	Cost   *big.Int   // gas * gas price, in $S wei
+	Profit *big.Float // reward value minus cost, in $S
+}
+
+func (p *Profitability) IsProfitable(minProfit *big.Float) bool {
+	return p.Profit.Cmp(minProfit) >= 0
+}
+
+func (g *profitGuard) profitability(reward *big.Int, gas uint64, gasPrice *big.Int) *Profitability {
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice)
+
+	profit := new(big.Float).Mul(accounting.ToEther(reward), g.price)
+	profit.Sub(profit, accounting.ToEther(cost))
+
+	return &Profitability{Reward: reward, Cost: cost, Profit: profit}
+}
+
+// refreshReward reads current reward from contract and caches it.
+func (s *Submitter) refreshReward(ctx context.Context) (*big.Int, error) {
+	reward, err := bind.Call(&s.powInstance, &bind.CallOpts{Context: ctx}, s.pow.PackReward(), s.pow.UnpackReward)
+	if err != nil {
+		return nil, err
+	}
+	s.guard.mu.Lock()
+	s.guard.reward = reward
+	s.guard.mu.Unlock()
+	return reward, nil
+}
+
+func (s *Submitter) GuardMode() GuardMode {
+	return s.guard.mode
+}
+
+// CheckProfitability estimates profit of the next submission with current
+// gas price and reward, and refreshes cached reward used by Submit.
+// Without guard every submission is profitable.
+func (s *Submitter) CheckProfitability(ctx context.Context) (*Profitability, bool, error) {
+	if s.guard.mode == GuardOff {
+		return nil, true, nil
+	}
+
+	gasPrice, err := s.conn.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	reward, err := s.refreshReward(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	profitability := s.guard.profitability(reward, s.guard.gas(), gasPrice)
+	return profitability, profitability.IsProfitable(s.guard.minProfit), nil
+}
+
+// checkSubmitProfitability is on the way of submission, so it uses cached
+// reward, it is read from contract only before the first CheckProfitability.
+func (s *Submitter) checkSubmitProfitability(ctx context.Context, gasPrice *big.Int) error {
+	if s.guard.mode == GuardOff {
+		return nil
+	}
+
+	s.guard.mu.Lock()
+	reward := s.guard.reward
+	s.guard.mu.Unlock()
+	if reward == nil {
+		var err error
+		if reward, err = s.refreshReward(ctx); err != nil {
+			return err
+		}
+	}
+
+	profitability := s.guard.profitability(reward, s.guard.gas(), gasPrice)
+	if !profitability.IsProfitable(s.guard.minProfit) {
+		return fmt.Errorf("%w: profit %s $S", ErrUnprofitable, profitability.Profit.Text('f', 6))
+	}
+	return nil
+}
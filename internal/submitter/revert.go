@@ -0,0 +1,48 @@
+package submitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"infinity/miner/internal/contracts/PoW"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var powABI, _ = PoW.PoWMetaData.ParseABI()
+
+// revertReason replays reverted submission at its block and decodes contract error name.
+func (s *Submitter) revertReason(ctx context.Context, callData []byte, gasPrice *big.Int, blockNumber *big.Int) string {
+	_, err := s.conn.CallContract(ctx, ethereum.CallMsg{
+		From:     s.Address,
+		To:       &s.powAddress,
+		Gas:      gasLimit,
+		GasPrice: gasPrice,
+		Data:     callData,
+	}, blockNumber)
+	if err == nil {
+		return "unknown"
+	}
+
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return "unknown"
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return "unknown"
+	}
+	data, err := hexutil.Decode(hexData)
+	if err != nil || len(data) < 4 {
+		return "unknown"
+	}
+	for name, abiErr := range powABI.Errors {
+		if bytes.Equal(data[:4], abiErr.ID[:4]) {
+			return name
+		}
+	}
+	return "unknown"
+}
@@ -3,12 +3,13 @@ package submitter
 import (
 	"context"
 	"crypto/ecdsa"
+	"fmt"
 	"infinity/miner/internal"
+	"infinity/miner/internal/config"
 	"infinity/miner/internal/contracts/PoW"
-	"log"
+	"infinity/miner/internal/utils"
 	"log/slog"
 	"math/big"
-	"os"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
@@ -30,30 +31,38 @@ type Submitter struct {
 	powAddress  common.Address
 	pow         PoW.PoW
 	powInstance bind.BoundContract
+
+	confirmations *confirmations
+	guard         *profitGuard
+	logger        *slog.Logger
 }
 
 const gasLimit = uint64(1_000_000)
 
-func NewSubmitter(conn *ethclient.Client) *Submitter {
-	chainId, err := conn.NetworkID(context.Background())
-	if err != nil {
-		log.Fatal(err)
-	}
+// receipt is not awaited longer, even if caller context has no deadline
+const receiptTimeout = 2 * time.Minute
 
-	PRIVATE_KEY := os.Getenv("INFINITY_PRIVATE_KEY")
-	if PRIVATE_KEY == "" {
-		log.Fatal("set INFINITY_PRIVATE_KEY variable")
-	}
+// nonce is synced after failed submit, even if caller context is canceled
+const nonceTimeout = 10 * time.Second
 
-	privateKey, err := crypto.HexToECDSA(PRIVATE_KEY)
+func NewSubmitter(ctx context.Context, conn *ethclient.Client, cfg *config.Config) (*Submitter, error) {
+	if cfg.PrivateKey == "" {
+		return nil, fmt.Errorf("private_key %w", config.ErrMissing)
+	}
+	privateKey, err := utils.HexToPrivateKey(cfg.PrivateKey)
 	if err != nil {
-		log.Fatal(err)
+		return nil, &internal.ParseError{What: "private key", Err: err}
 	}
 	address := crypto.PubkeyToAddress(privateKey.PublicKey)
 
-	nonce, err := conn.PendingNonceAt(context.Background(), address)
+	chainId, err := conn.NetworkID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := conn.PendingNonceAt(ctx, address)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	pow := *PoW.NewPoW()
@@ -69,7 +78,11 @@ func NewSubmitter(conn *ethclient.Client) *Submitter {
 		powAddress:  powAddress,
 		pow:         pow,
 		powInstance: *powInstance,
-	}
+
+		confirmations: &confirmations{depth: cfg.Confirmations},
+		guard:         newProfitGuard(cfg),
+		logger:        slog.With("component", "submitter"),
+	}, nil
 }
 
 func waitForTransactionReceipt(ctx context.Context, c ethclient.Client, txHash common.Hash) (*types.Receipt, error) {
@@ -90,14 +103,41 @@ func waitForTransactionReceipt(ctx context.Context, c ethclient.Client, txHash c
 	}
 }
 
-func (s *Submitter) GetBalance() (*big.Int, error) {
-	return s.conn.PendingBalanceAt(context.Background(), s.Address)
+// syncNonce resets nonce to the pending one, so dropped or unsent tx leaves no gap.
+func (s *Submitter) syncNonce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), nonceTimeout)
+	defer cancel()
+	nonce, err := s.conn.PendingNonceAt(ctx, s.Address)
+	if err != nil {
+		s.logger.Warn("Cant sync nonce", "tx_nonce", s.nonce, "err", err)
+		return
+	}
+	if nonce != s.nonce {
+		s.logger.Info("Nonce synced", "from", s.nonce, "to", nonce)
+	}
+	s.nonce = nonce
+}
+
+func (s *Submitter) GetBalance(ctx context.Context) (*big.Int, error) {
+	return s.conn.PendingBalanceAt(ctx, s.Address)
 }
 
-func (s *Submitter) Submit(privateKeyB ecdsa.PrivateKey, privateKeyAB ecdsa.PrivateKey) (bool, error) {
-	gasPrice, err := s.conn.SuggestGasPrice(context.Background())
+type SubmitResult struct {
+	TxHash        common.Hash
+	Nonce         uint64
+	GasPrice      *big.Int
+	GasCost       *big.Int // gas used * effective gas price, nil until receipt
+	Reverted      bool
+	RevertReason  string // contract error name, if reverted
+	IsNextProblem bool
+	Submission    *Submission // our winning submission, if any
+}
+
+// Submit sends solution and waits for its receipt, until ctx is canceled.
+func (s *Submitter) Submit(ctx context.Context, privateKeyB ecdsa.PrivateKey, privateKeyAB ecdsa.PrivateKey) (*SubmitResult, error) {
+	gasPrice, err := s.conn.SuggestGasPrice(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	// signature
@@ -108,10 +148,17 @@ func (s *Submitter) Submit(privateKeyB ecdsa.PrivateKey, privateKeyAB ecdsa.Priv
 	digest := crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n32"), crypto.Keccak256(packed))
 	signature, err := crypto.Sign(digest, &privateKeyAB)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	signature[crypto.RecoveryIDOffset] += 27
 
+	publicKeyB := PoW.ECCPoint{X: privateKeyB.PublicKey.X, Y: privateKeyB.PublicKey.Y}
+	callData := s.pow.PackSubmit(s.Address, publicKeyB, signature, data)
+	err = s.checkSubmitProfitability(ctx, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := s.powInstance.Transact(
 		&bind.TransactOpts{
 			Nonce: big.NewInt(int64(s.nonce)),
@@ -120,26 +167,51 @@ func (s *Submitter) Submit(privateKeyB ecdsa.PrivateKey, privateKeyAB ecdsa.Priv
 			},
 			GasLimit: gasLimit,
 			GasPrice: gasPrice,
+			Context:  ctx,
 		},
 		"submit",
 		s.Address,
-		PoW.ECCPoint{X: privateKeyB.PublicKey.X, Y: privateKeyB.PublicKey.Y},
+		publicKeyB,
 		signature,
 		data,
 	)
 	if err != nil {
-		return false, err
+		s.syncNonce(ctx)
+		return nil, err
 	}
 
+	result := &SubmitResult{
+		TxHash:   tx.Hash(),
+		Nonce:    s.nonce,
+		GasPrice: gasPrice,
+	}
 	s.nonce++
-	slog.Debug("Submission transaction sended", "tx", tx.Hash())
+	s.logger.Info("Submission transaction sent", "tx", tx.Hash(), "tx_nonce", result.Nonce)
 
-	receipt, err := waitForTransactionReceipt(context.Background(), s.conn, tx.Hash())
+	receiptCtx, cancel := context.WithTimeout(ctx, receiptTimeout)
+	defer cancel()
+	receipt, err := waitForTransactionReceipt(receiptCtx, s.conn, tx.Hash())
 	if err != nil {
-		return false, err
+		// tx may be dropped, its nonce must be reused
+		s.syncNonce(ctx)
+		return result, err
 	}
+	effectiveGasPrice := receipt.EffectiveGasPrice
+	if effectiveGasPrice == nil {
+		effectiveGasPrice = gasPrice
+	}
+	result.GasCost = new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), effectiveGasPrice)
 	if receipt.Status == types.ReceiptStatusFailed {
-		return true, err
+		result.Reverted = true
+		result.RevertReason = s.revertReason(ctx, callData, gasPrice, receipt.BlockNumber)
+		result.IsNextProblem = true
+		return result, nil
+	}
+
+	if submission := s.findSubmission(receipt); submission != nil {
+		result.Submission = submission
+		s.confirmations.add(submission)
+		s.guard.observeWin(receipt.GasUsed)
 	}
 
 	for _, log := range receipt.Logs {
@@ -148,8 +220,9 @@ func (s *Submitter) Submit(privateKeyB ecdsa.PrivateKey, privateKeyAB ecdsa.Priv
 		}
 		newProblem, _ := s.pow.UnpackNewProblemEvent(log)
 		if newProblem != nil {
-			return true, nil
+			result.IsNextProblem = true
+			return result, nil
 		}
 	}
-	return false, nil
+	return result, nil
 }
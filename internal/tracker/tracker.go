@@ -0,0 +1,85 @@
+package tracker
+
+import (
+	"infinity/miner/internal/contracts/PoW"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type Problem struct {
+	PoW.PoWNewProblem
+	BlockNumber uint64
+	SeenAt      time.Time
+}
+
+// Tracker keeps the newest known problem. Problems only move forward in nonce,
+// so late deliveries (initial CurrentProblem call, resubscriptions) cannot
+// replace newer work.
+type Tracker struct {
+	mu      sync.Mutex
+	current *Problem
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Update returns true if problem is newer than the current one and was accepted.
+func (t *Tracker) Update(problem PoW.PoWNewProblem, blockNumber uint64) bool {
+	if problem.Raw != nil && problem.Raw.Removed {
+		return false
+	}
+	if problem.Nonce == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current != nil && problem.Nonce.Cmp(t.current.Nonce) <= 0 {
+		return false
+	}
+
+	t.current = &Problem{
+		PoWNewProblem: problem,
+		BlockNumber:   blockNumber,
+		SeenAt:        time.Now(),
+	}
+	return true
+}
+
+// Remove forgets current problem if it matches problem removed by reorg,
+// so canonical problem can be accepted even if it has lower nonce.
+func (t *Tracker) Remove(problem PoW.PoWNewProblem) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current == nil || problem.Nonce == nil || problem.Nonce.Cmp(t.current.Nonce) != 0 {
+		return false
+	}
+	// problem could be already re-included in another block
+	if problem.Raw != nil && t.current.Raw != nil &&
+		t.current.Raw.BlockHash != (common.Hash{}) && problem.Raw.BlockHash != t.current.Raw.BlockHash {
+		return false
+	}
+	t.current = nil
+	return true
+}
+
+func (t *Tracker) Current() *Problem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+func (t *Tracker) CurrentNonce() *big.Int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return nil
+	}
+	return t.current.Nonce
+}
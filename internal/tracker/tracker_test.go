@@ -0,0 +1,82 @@
+package tracker
+
+import (
+	"infinity/miner/internal/contracts/PoW"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func problem(nonce int64, block byte, removed bool) PoW.PoWNewProblem {
+	return PoW.PoWNewProblem{
+		Nonce: big.NewInt(nonce),
+		Raw:   &types.Log{BlockHash: common.Hash{block}, Removed: removed},
+	}
+}
+
+func TestTrackerOrdering(t *testing.T) {
+	type step struct {
+		remove  bool
+		problem PoW.PoWNewProblem
+		ok      bool
+		current int64 // 0 - no current problem
+	}
+	for _, test := range []struct {
+		name  string
+		steps []step
+	}{
+		{"newer nonce replaces", []step{
+			{problem: problem(1, 1, false), ok: true, current: 1},
+			{problem: problem(2, 2, false), ok: true, current: 2},
+		}},
+		{"late delivery is ignored", []step{
+			{problem: problem(2, 2, false), ok: true, current: 2},
+			{problem: problem(1, 1, false), current: 2},
+			{problem: problem(2, 2, false), current: 2},
+		}},
+		{"removed log is not accepted", []step{
+			{problem: problem(1, 1, true)},
+		}},
+		{"removed problem lets lower nonce in", []step{
+			{problem: problem(1, 1, false), ok: true, current: 1},
+			{problem: problem(2, 2, false), ok: true, current: 2},
+			{remove: true, problem: problem(2, 2, true), ok: true},
+			{problem: problem(1, 3, false), ok: true, current: 1},
+		}},
+		{"remove of other nonce is ignored", []step{
+			{problem: problem(2, 2, false), ok: true, current: 2},
+			{remove: true, problem: problem(1, 1, true), current: 2},
+		}},
+		{"remove after re-inclusion is ignored", []step{
+			{problem: problem(2, 2, false), ok: true, current: 2},
+			{remove: true, problem: problem(2, 2, true), ok: true},
+			{problem: problem(2, 3, false), ok: true, current: 2},
+			{remove: true, problem: problem(2, 2, true), current: 2},
+		}},
+		{"remove without current", []step{
+			{remove: true, problem: problem(1, 1, true)},
+			{problem: problem(1, 1, false), ok: true, current: 1},
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := NewTracker()
+			for i, step := range test.steps {
+				var ok bool
+				if step.remove {
+					ok = tracker.Remove(step.problem)
+				} else {
+					ok = tracker.Update(step.problem, 0)
+				}
+				if ok != step.ok {
+					t.Fatalf("step %d: got %v, want %v", i, ok, step.ok)
+				}
+				current := tracker.CurrentNonce()
+				if (current == nil) != (step.current == 0) || current != nil && current.Int64() != step.current {
+					t.Fatalf("step %d: got current %v, want %d", i, current, step.current)
+				}
+			}
+		})
+	}
+}
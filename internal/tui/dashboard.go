@@ -0,0 +1,309 @@
+// Package tui shows full screen terminal dashboard of running miner.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/utils"
+	"infinity/miner/pkg/miner"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	refreshInterval = time.Second
+	sparklineWidth  = 40
+	maxSubmissions  = 8
+	LogLines        = 10
+
+	// ANSI escape sequences
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	home        = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+	bold        = "\x1b[1m"
+	red         = "\x1b[31m"
+	green       = "\x1b[32m"
+	yellow      = "\x1b[33m"
+	reset       = "\x1b[0m"
+)
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+type submissionRow struct {
+	at     time.Time
+	nonce  string
+	tx     common.Hash
+	status string
+	gas    string
+}
+
+type Dashboard struct {
+	miner *miner.Miner
+	logs  *LogBuffer
+	out   io.Writer
+
+	mu          sync.Mutex
+	submissions []*submissionRow
+	history     [][]float64 // 10s hashrate samples per solver
+}
+
+// New creates dashboard and registers miner handlers, so it must be called before miner.Start.
+func New(m *miner.Miner, logs *LogBuffer, out io.Writer) *Dashboard {
+	d := &Dashboard{miner: m, logs: logs, out: out}
+	m.OnSubmission(d.onSubmission)
+	m.OnConfirmation(d.onConfirmation)
+	return d
+}
+
+func (d *Dashboard) onSubmission(submission miner.Submission) {
+	row := &submissionRow{at: submission.At, nonce: submission.Nonce.String(), gas: "-"}
+	result := submission.Result
+	switch {
+	case result == nil && submission.Err != nil:
+		row.status = red + "failed: " + submission.Err.Error() + reset
+	case result == nil:
+		row.status = "not sent"
+	case submission.Err != nil:
+		row.tx = result.TxHash
+		row.status = red + "failed: " + submission.Err.Error() + reset
+	case result.Reverted:
+		row.tx = result.TxHash
+		row.status = red + "reverted: " + result.RevertReason + reset
+	case result.Submission != nil:
+		row.tx = result.TxHash
+		row.status = yellow + "won, pending" + reset
+	default:
+		row.tx = result.TxHash
+		row.status = "mined, lost"
+	}
+	if result != nil && result.GasCost != nil {
+		row.gas = accounting.ToEther(result.GasCost).Text('f', 6) + " $S"
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.submissions = append(d.submissions, row)
+	if len(d.submissions) > maxSubmissions {
+		d.submissions = d.submissions[len(d.submissions)-maxSubmissions:]
+	}
+}
+
+func (d *Dashboard) onConfirmation(confirmation miner.Confirmation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, row := range d.submissions {
+		if row.tx != confirmation.TxHash {
+			continue
+		}
+		if confirmation.Status == miner.SubmissionConfirmed {
+			row.status = fmt.Sprintf("%sconfirmed +%s INFINITY%s", green, accounting.ToEther(confirmation.Reward).Text('f', 4), reset)
+		} else {
+			row.status = yellow + confirmation.Status.String() + reset
+		}
+	}
+}
+
+// Run redraws dashboard until ctx is canceled, then restores terminal.
+func (d *Dashboard) Run(ctx context.Context) {
+	io.WriteString(d.out, enterScreen)
+	defer io.WriteString(d.out, leaveScreen)
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		d.draw()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dashboard) draw() {
+	stats := d.miner.Stats()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for len(d.history) < len(stats.Solvers) {
+		d.history = append(d.history, nil)
+	}
+	d.history = d.history[:len(stats.Solvers)]
+	for i, solver := range stats.Solvers {
+		d.history[i] = append(d.history[i], solver.Hashrate.Rate10s)
+		if len(d.history[i]) > sparklineWidth {
+			d.history[i] = d.history[i][1:]
+		}
+	}
+
+	// sections are fitted to terminal below, lines wrapping or scrolling
+	// the screen would break redraw
+	var top, threads, bottom, logs []string
+	lines := &top
+	line := func(format string, args ...any) {
+		*lines = append(*lines, fmt.Sprintf(format, args...))
+	}
+
+	state := green + "mining" + reset
+	if stats.Paused {
+		state = yellow + "paused (" + stats.PauseReason + ")" + reset
+	}
+	uptime := time.Duration(0)
+	if !stats.StartedAt.IsZero() {
+		uptime = time.Since(stats.StartedAt).Round(time.Second)
+	}
+	line("%s∞ INFINITY miner%s  %s  up %s", bold, reset, state, uptime)
+	line("")
+
+	if problem := stats.CurrentProblem; problem != nil {
+		line("Problem    nonce %s  difficulty %s  block %d  age %s",
+			problem.Nonce,
+			common.BigToAddress(problem.Difficulty),
+			problem.BlockNumber,
+			time.Since(problem.SeenAt).Round(time.Second),
+		)
+	} else {
+		line("Problem    waiting")
+	}
+	listener := green + "connected" + reset
+	if !stats.Listening {
+		listener = red + "disconnected" + reset
+	}
+	line("Listener   %s", listener)
+	balance := "-"
+	if stats.Balance != nil {
+		balance = accounting.ToEther(stats.Balance).Text('f', 4) + " $S"
+	}
+	line("Balance    %s  rewards %s INFINITY  gas %s $S",
+		balance,
+		accounting.ToEther(stats.Rewards).Text('f', 4),
+		accounting.ToEther(stats.GasSpent).Text('f', 6),
+	)
+	line("Hashrate   %s (10s)  %s (1m)  %s (15m)",
+		utils.FormatHashrate(stats.Hashrates.Rate10s),
+		utils.FormatHashrate(stats.Hashrates.Rate1m),
+		utils.FormatHashrate(stats.Hashrates.Rate15m),
+	)
+	line("Solutions  %d found  %d submitted  %d confirmed", stats.Solutions, stats.Submits, stats.Confirmed)
+	line("")
+
+	line("%sThreads%s", bold, reset)
+	lines = &threads
+	for i, solver := range stats.Solvers {
+		line("  %3d  %s  %s", i, sparkline(d.history[i]), utils.FormatHashrate(solver.Hashrate.Rate10s))
+	}
+	lines = &bottom
+	line("")
+
+	if len(stats.Workers) > 0 {
+		line("%sWorkers%s", bold, reset)
+		for _, worker := range stats.Workers {
+			line("  %-16s  %-21s  %3d threads  %s  %d solutions",
+				worker.Name,
+				worker.Addr,
+				worker.Threads,
+				utils.FormatHashrate(worker.Hashrate.Rate10s),
+				worker.Solutions,
+			)
+		}
+		line("")
+	}
+
+	line("%sSubmissions%s", bold, reset)
+	if len(d.submissions) == 0 {
+		line("  none yet")
+	}
+	for i := len(d.submissions) - 1; i >= 0; i-- {
+		row := d.submissions[i]
+		tx := "-"
+		if row.tx != (common.Hash{}) {
+			tx = row.tx.Hex()[:10] + "…"
+		}
+		line("  %s  nonce %-6s  %-12s  gas %-12s  %s", row.at.Format(time.TimeOnly), row.nonce, tx, row.gas, row.status)
+	}
+	line("")
+
+	line("%sLog%s", bold, reset)
+	lines = &logs
+	for _, logLine := range d.logs.Lines() {
+		line("  %s", logLine)
+	}
+
+	width, height := terminalSize(d.out)
+	var b strings.Builder
+	for _, line := range fit(top, threads, bottom, logs, height) {
+		if width > 0 {
+			line = truncate(line, width)
+		}
+		b.WriteString(line + clearLine + "\n")
+	}
+	io.WriteString(d.out, home+b.String()+clearBelow)
+}
+
+// fit caps thread and log rows, so dashboard takes less than height rows
+// (the last one is left empty, newline there would scroll). Log gets up to
+// half of free rows, threads the rest. Zero height means unknown size.
+func fit(top, threads, bottom, logs []string, height int) []string {
+	if height > 0 {
+		free := max(height-1-len(top)-len(bottom), 0)
+		logRows := min(len(logs), free/2)
+		if len(threads) > free-logRows {
+			threadRows := max(free-logRows-1, 0)
+			hidden := len(threads) - threadRows
+			threads = append(threads[:threadRows:threadRows], fmt.Sprintf("  … %d more threads", hidden))
+		}
+		logRows = min(len(logs), free-len(threads))
+		logs = logs[len(logs)-max(logRows, 0):]
+	}
+
+	lines := append(append(append(top, threads...), bottom...), logs...)
+	if height > 0 && len(lines) > height-1 {
+		lines = lines[:max(height-1, 0)]
+	}
+	return lines
+}
+
+// truncate cuts line to width visible runes, ANSI escape sequences are kept.
+func truncate(line string, width int) string {
+	visible := 0
+	escape := false
+	for i, r := range line {
+		switch {
+		case escape:
+			// sequences used here end with a letter
+			escape = !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z')
+		case r == '\x1b':
+			escape = true
+		default:
+			if visible == width {
+				return line[:i] + reset
+			}
+			visible++
+		}
+	}
+	return line
+}
+
+// sparkline draws values scaled to their peak, left padded to sparklineWidth.
+func sparkline(values []float64) string {
+	peak := 0.0
+	for _, value := range values {
+		peak = max(peak, value)
+	}
+	spark := make([]rune, len(values))
+	for i, value := range values {
+		level := 0
+		if peak > 0 {
+			level = int(value / peak * float64(len(sparks)-1))
+		}
+		spark[i] = sparks[level]
+	}
+	return strings.Repeat(" ", max(sparklineWidth-len(spark), 0)) + string(spark)
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func numbered(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return lines
+}
+
+func TestFit(t *testing.T) {
+	top, bottom := numbered("top", 5), numbered("bottom", 3)
+	for _, test := range []struct {
+		threads, logs, height int
+		wantThreads, wantLogs int // rows, including "more threads"
+	}{
+		{threads: 4, logs: 10, height: 0, wantThreads: 4, wantLogs: 10},
+		{threads: 4, logs: 2, height: 40, wantThreads: 4, wantLogs: 2},
+		{threads: 64, logs: 10, height: 30, wantThreads: 11, wantLogs: 10},
+		{threads: 64, logs: 10, height: 20, wantThreads: 6, wantLogs: 5},
+		{threads: 2, logs: 10, height: 20, wantThreads: 2, wantLogs: 9},
+	} {
+		lines := fit(top, numbered("thread", test.threads), bottom, numbered("log", test.logs), test.height)
+		if test.height > 0 && len(lines) > test.height-1 {
+			t.Errorf("%+v: got %d lines", test, len(lines))
+		}
+		if want := len(top) + test.wantThreads + len(bottom) + test.wantLogs; len(lines) != want {
+			t.Errorf("%+v: got %d lines, want %d", test, len(lines), want)
+			continue
+		}
+		if test.logs > 0 && lines[len(lines)-1] != fmt.Sprintf("log%d", test.logs-1) {
+			t.Errorf("%+v: last log line is %q", test, lines[len(lines)-1])
+		}
+	}
+
+	if lines := fit(top, nil, bottom, nil, 4); len(lines) != 3 {
+		t.Errorf("got %d lines for tiny terminal, want 3", len(lines))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, test := range []struct {
+		line  string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"too long", 3, "too" + reset},
+		{green + "∞∞∞∞" + reset + "x", 3, green + "∞∞∞" + reset},
+		{bold + "ab" + reset + "cd", 3, bold + "ab" + reset + "c" + reset},
+	} {
+		if got := truncate(test.line, test.width); got != test.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", test.line, test.width, got, test.want)
+		}
+	}
+}
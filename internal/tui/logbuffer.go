@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// LogBuffer keeps last log lines for dashboard. After Detach lines are
+// written to given writer, so loggers created while dashboard was shown
+// keep working.
+type LogBuffer struct {
+	mu      sync.Mutex
+	max     int
+	lines   []string
+	partial []byte
+	out     io.Writer
+}
+
+func NewLogBuffer(max int) *LogBuffer {
+	return &LogBuffer{max: max}
+}
+
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.out != nil {
+		return b.out.Write(p)
+	}
+
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+		b.lines = append(b.lines, string(b.partial[:i]))
+		b.partial = b.partial[i+1:]
+	}
+	if len(b.lines) > b.max {
+		b.lines = append(b.lines[:0], b.lines[len(b.lines)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.lines...)
+}
+
+// Detach flushes kept lines to out and sends further writes there.
+func (b *LogBuffer) Detach(out io.Writer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, line := range b.lines {
+		io.WriteString(out, line+"\n")
+	}
+	b.lines = nil
+	b.out = out
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package tui
+
+import "io"
+
+func terminalSize(out io.Writer) (int, int) {
+	return 0, 0
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package tui
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalSize returns columns and rows of terminal out, zeros if out is not a terminal.
+func terminalSize(out io.Writer) (int, int) {
+	f, ok := out.(*os.File)
+	if !ok {
+		return 0, 0
+	}
+	size, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0
+	}
+	return int(size.Col), int(size.Row)
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"math/big"
+)
+
+// address space size, solution is address with address^magic < difficulty
+var addressSpace = new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 160))
+
+// ExpectedTries returns mean number of tries to find a solution for difficulty.
+func ExpectedTries(difficulty *big.Int) float64 {
+	if difficulty == nil || difficulty.Sign() <= 0 {
+		return 0
+	}
+	tries, _ := new(big.Float).Quo(addressSpace, new(big.Float).SetInt(difficulty)).Float64()
+	return tries
+}
@@ -5,10 +5,20 @@ import (
 	"time"
 )
 
-func FormatHashrate(numTries uint64, start time.Time) string {
+func Hashrate(numTries uint64, start time.Time) float64 {
 	t := time.Now()
 	elapsed := t.Sub(start)
-	hasrate := float64(numTries) * float64(time.Second) / float64(elapsed)
-	return fmt.Sprintf("%f H/s", hasrate)
+	return float64(numTries) * float64(time.Second) / float64(elapsed)
+}
+
+var hashrateUnits = []string{"H/s", "kH/s", "MH/s", "GH/s", "TH/s", "PH/s"}
 
+// FormatHashrate formats rate in H/s with scaled unit, e.g. 1.23 MH/s.
+func FormatHashrate(rate float64) string {
+	unit := 0
+	for rate >= 1000 && unit < len(hashrateUnits)-1 {
+		rate /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.2f %s", rate, hashrateUnits[unit])
 }
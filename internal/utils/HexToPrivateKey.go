@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// HexToPrivateKey parses hex private key with or without 0x prefix.
+func HexToPrivateKey(raw string) (*ecdsa.PrivateKey, error) {
+	return crypto.HexToECDSA(strings.TrimPrefix(raw, "0x"))
+}
@@ -1,131 +1,110 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"infinity/miner/internal"
-	"infinity/miner/internal/contracts/PoW"
-	"infinity/miner/internal/listener"
-	"infinity/miner/internal/solver"
-	"infinity/miner/internal/submitter"
-	"infinity/miner/internal/utils"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/logging"
 	"log"
-	"log/slog"
-	"math/big"
 	"os"
-	"runtime"
-	"time"
+	"sort"
+	"strings"
 
-	bind2 "github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/params"
-	"github.com/joho/godotenv"
 )
 
-func main() {
-	N := runtime.NumCPU()
+// set with -ldflags "-X main.version=..."
+var version = "dev"
 
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
+type command struct {
+	usage string
+	run   func(cfg *config.Loaded, args []string)
+}
 
-	RPC := os.Getenv("INFINITY_RPC")
-	if RPC == "" {
-		log.Fatal("set INFINITY_RPC variable")
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"mine":          {"mine problems and submit solutions (default)", runMine},
+		"bench":         {"measure hashrate without network", runBench},
+		"coordinator":   {"mine with solvers of workers connected to coordinator_addr", runCoordinator},
+		"status":        {"print contract state, current problem and account balance", runStatus},
+		"submit-manual": {"submit given private key B for the current problem", runSubmitManual},
+		"resubmit":      {"submit logged solutions of the current problem", runResubmit},
+		"verify":        {"check private key B against a problem", runVerify},
+		"stats":         {"network statistics, see stats network", runStats},
+		"config":        {"print effective config, see config print", runConfig},
+		"notify":        {"send test event to webhook, see notify test", runNotify},
+		"history":       {"problems, solutions and transactions from history_file", runHistory},
+		"solutions":     {"decrypt solution log or generate its key, see solutions decrypt", runSolutions},
+		"version":       {"print version", runVersion},
+		"worker":        {"run solvers for coordinator at coordinator_addr", runWorker},
 	}
+}
 
-	conn, err := ethclient.Dial(RPC)
-	if err != nil {
-		log.Fatal(err)
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: miner [flags] [command] [command flags]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nrun miner -help for flags\n")
+}
 
-	pow := PoW.NewPoW()
-	instance := pow.Instance(
-		conn,
-		common.HexToAddress(internal.PoWAddress),
-	)
+func dial(ctx context.Context, cfg *config.Config) *ethclient.Client {
+	if cfg.RPC == "" {
+		log.Fatalf("rpc %s (INFINITY_RPC or -rpc)", config.ErrMissing)
+	}
 
-	submitter := submitter.NewSubmitter(conn)
-	problems, err := listener.SubscribeToProblems()
+	conn, err := ethclient.DialContext(ctx, cfg.RPC)
 	if err != nil {
-		log.Fatal("Cant subscribe for problems", err)
+		log.Fatal(internal.NewDialError(cfg.RPC, err))
 	}
+	return conn
+}
 
-	submitterBalance, err := submitter.GetBalance()
+func main() {
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		usage()
+		return
+	}
 	if err != nil {
-		log.Fatal("Cant get submitter balance")
+		log.Fatal(err)
 	}
-	log.Printf("∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞")
-	log.Printf("Submitter address: %s", submitter.Address)
-	log.Printf("Submitter balance: %f $S", new(big.Float).Quo(new(big.Float).SetInt(submitterBalance), big.NewFloat(params.Ether)))
-	log.Printf("Ensure, that it have enough funds")
-	log.Printf("∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞∞")
-
-	solutionCh := make(chan solver.Solution, N)
-	solvers := make([]*solver.Solver, N)
-	for i := range len(solvers) {
-		solvers[i] = solver.NewSolver()
-		go solvers[i].Solve(solutionCh)
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatal(err)
 	}
 
-	go func() {
-		currentProblem, _ := bind2.Call(instance, nil, pow.PackCurrentProblem(), pow.UnpackCurrentProblem)
-		problems <- PoW.PoWNewProblem{
-			Nonce:       currentProblem.Arg0,
-			PrivateKeyA: currentProblem.Arg1,
-			Difficulty:  currentProblem.Arg2,
-		}
-	}()
-
-	startTime := time.Now()
-	ticker := time.NewTicker(time.Minute / 10)
-	defer ticker.Stop()
-
-	totalProblems := uint64(0)
-	totalSubmits := uint64(0)
-
-	var currentProblemNonce *big.Int
-	for {
-		select {
-		case problem := <-problems:
-			totalProblems += 1
-			currentProblemNonce = problem.Nonce
-			log.Printf("Got new problem: %s", common.BigToAddress(problem.Difficulty))
-			for _, solver := range solvers {
-				go func() {
-					solver.ProblemCh <- problem
-				}()
-			}
-		case solution := <-solutionCh:
-			if solution.Nonce.Cmp(currentProblemNonce) != 0 {
-				continue
-			}
-
-			privateKeyAB, _ := utils.EcAdd(solution.PrivateKeyA, solution.PrivateKeyB)
-			isNextProblem, err := submitter.Submit(solution.PrivateKeyB, *privateKeyAB)
-			if err == nil {
-				totalSubmits += 1
-			} else {
-				slog.Debug("Submission failed", "err", err)
-			}
-			if isNextProblem {
-				currentProblemNonce = big.NewInt(-1)
-			}
-		case <-ticker.C:
-			totalTries := uint64(0)
-			totalSolutions := uint64(0)
-			for _, solver := range solvers {
-				totalTries += solver.NumTries
-				totalSolutions += solver.NumSolutions
-			}
+	name := "mine"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	cmd.run(cfg, args)
+}
 
-			log.Printf(
-				"num problems: %d, num solutions: %d, hashrate: %s",
-				totalProblems,
-				totalSolutions,
-				utils.FormatHashrate(totalTries, startTime),
-			)
-		}
+func runVersion(_ *config.Loaded, _ []string) {
+	fmt.Println(version)
+}
 
+func runConfig(cfg *config.Loaded, args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		log.Fatal("usage: miner [flags] config print")
+	}
+	cfg.Print(os.Stdout)
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("\n# invalid config:\n# %s\n", strings.ReplaceAll(err.Error(), "\n", "\n# "))
 	}
 }
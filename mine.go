@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/logging"
+	"infinity/miner/internal/tui"
+	"infinity/miner/pkg/miner"
+	"log"
+	"os"
+)
+
+func runMine(cfg *config.Loaded, args []string) {
+	flags := flag.NewFlagSet("mine", flag.ExitOnError)
+	dashboard := flags.Bool("dashboard", false, "show full screen dashboard instead of log")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("usage: miner [flags] mine [-dashboard]")
+	}
+
+	mine(cfg, *dashboard, func() (*miner.Miner, error) {
+		return miner.New(cfg.Config)
+	})
+}
+
+// runCoordinator mines with solvers of workers, see runWorker.
+func runCoordinator(cfg *config.Loaded, args []string) {
+	flags := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	dashboard := flags.Bool("dashboard", false, "show full screen dashboard instead of log")
+	local := flags.Bool("local", false, "also run solvers on this machine")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("usage: miner [flags] coordinator [-local] [-dashboard]")
+	}
+
+	mine(cfg, *dashboard, func() (*miner.Miner, error) {
+		if err := cfg.ValidateFarm(); err != nil {
+			return nil, err
+		}
+		m, err := miner.New(cfg.Config)
+		if err != nil {
+			return nil, err
+		}
+		m.ServeWorkers(cfg.CoordinatorAddr, cfg.FarmToken, *local)
+		return m, nil
+	})
+}
+
+// mine creates miner with newMiner after logging is set up, because
+// components bind their loggers when they are created.
+func mine(cfg *config.Loaded, dashboard bool, newMiner func() (*miner.Miner, error)) {
+	var logs *tui.LogBuffer
+	if dashboard {
+		logs = tui.NewLogBuffer(tui.LogLines)
+		if err := logging.Setup(logs, cfg.LogLevel, cfg.LogFormat); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	m, err := newMiner()
+	if err != nil {
+		if logs != nil {
+			logs.Detach(os.Stderr)
+		}
+		log.Fatal("Invalid config:\n", err)
+	}
+	var screen *tui.Dashboard
+	if dashboard {
+		screen = tui.New(m, logs, os.Stdout)
+	}
+
+	ctx := shutdownContext(cfg.ShutdownTimeout)
+	if err := m.Start(ctx); err != nil {
+		if logs != nil {
+			logs.Detach(os.Stderr)
+		}
+		log.Fatal(err)
+	}
+
+	if screen != nil {
+		screenCtx, closeScreen := context.WithCancel(ctx)
+		go func() {
+			<-m.Done()
+			closeScreen()
+		}()
+		screen.Run(screenCtx)
+		// show shutdown progress and final stats in plain log
+		logs.Detach(os.Stderr)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-m.Done():
+	}
+	m.Stop()
+	log.Printf("Bye")
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/notify"
+	"log"
+)
+
+func runNotify(cfg *config.Loaded, args []string) {
+	if len(args) == 0 || args[0] != "test" {
+		log.Fatal("usage: miner [flags] notify test")
+	}
+	if cfg.WebhookURL == "" {
+		log.Fatalf("webhook_url %s (INFINITY_WEBHOOK_URL or -webhook-url)", config.ErrMissing)
+	}
+
+	notifier, err := notify.New(cfg.WebhookURL, cfg.WebhookTemplate, cfg.WebhookEvents)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+	err = notifier.Send(ctx, notify.Event{
+		Type:    notify.Test,
+		Message: "Test notification",
+		Data:    map[string]string{"version": version},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Test notification sent")
+}
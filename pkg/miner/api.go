@@ -0,0 +1,49 @@
+package miner
+
+import (
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/api"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// apiController exposes miner to local control API.
+type apiController struct {
+	*Miner
+}
+
+func (c apiController) Status() api.Status {
+	stats := c.Stats()
+	status := api.Status{
+		Hashrate:    stats.Hashrate,
+		Hashrate10s: stats.Hashrates.Rate10s,
+		Hashrate15m: stats.Hashrates.Rate15m,
+		Threads:     stats.Threads,
+		Paused:      stats.Paused,
+		PauseReason: stats.PauseReason,
+	}
+	if stats.Balance != nil {
+		status.Balance = accounting.ToEther(stats.Balance).Text('f', 18)
+	}
+	if problem := stats.CurrentProblem; problem != nil {
+		status.Problem = &api.Problem{
+			Nonce:       problem.Nonce.String(),
+			Difficulty:  common.BigToAddress(problem.Difficulty).Hex(),
+			BlockNumber: problem.BlockNumber,
+			SeenAt:      problem.SeenAt,
+		}
+	}
+	if submission := stats.LastSubmission; submission != nil {
+		last := &api.Submission{ProblemNonce: submission.Nonce.String(), At: submission.At}
+		if submission.Result != nil {
+			last.TxHash = submission.Result.TxHash.Hex()
+			last.Reverted = submission.Result.Reverted
+			last.RevertReason = submission.Result.RevertReason
+		}
+		if submission.Err != nil {
+			last.Error = submission.Err.Error()
+		}
+		status.LastSubmission = last
+	}
+	return status
+}
@@ -0,0 +1,1032 @@
+// Package miner runs INFINITY PoW mining: it follows problems published by
+// the PoW contract, solves them on local cpus and submits solutions.
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"infinity/miner/internal"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/api"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/estimator"
+	"infinity/miner/internal/farm"
+	"infinity/miner/internal/hashrate"
+	"infinity/miner/internal/history"
+	"infinity/miner/internal/listener"
+	"infinity/miner/internal/metrics"
+	"infinity/miner/internal/notify"
+	"infinity/miner/internal/preflight"
+	"infinity/miner/internal/solutionlog"
+	"infinity/miner/internal/solver"
+	"infinity/miner/internal/submitter"
+	"infinity/miner/internal/tracker"
+	"infinity/miner/internal/utils"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type Config = config.Config
+
+// Errors returned by New and Start, and reported by Err.
+var (
+	ErrMissingConfig = config.ErrMissing
+	ErrWrongChain    = preflight.ErrWrongChain
+	ErrNoContract    = preflight.ErrNoContract
+	ErrPaused        = preflight.ErrPaused
+)
+
+type DialError = internal.DialError
+type ParseError = internal.ParseError
+
+func DefaultConfig() *Config {
+	return config.Default()
+}
+
+type Problem = tracker.Problem
+type SubmitResult = submitter.SubmitResult
+type Confirmation = submitter.Submission
+type SubmissionStatus = submitter.SubmissionStatus
+
+// Statuses of Confirmation
+const (
+	SubmissionConfirmed   = submitter.SubmissionConfirmed
+	SubmissionUnconfirmed = submitter.SubmissionUnconfirmed // removed by reorg
+)
+
+type Solution struct {
+	Nonce     *big.Int
+	AddressAB common.Address
+	FoundAt   time.Time
+}
+
+type Submission struct {
+	Nonce  *big.Int
+	Result *SubmitResult // nil if transaction was not sent
+	Err    error
+	At     time.Time
+}
+
+// Rates are hashrates in H/s averaged over 10 seconds, 1 minute and 15 minutes.
+type Rates = hashrate.Rates
+type SolverStats = metrics.SolverStats
+type WorkerStats = farm.WorkerStats
+
+type Stats struct {
+	StartedAt       time.Time
+	Problems        uint64
+	Solutions       uint64
+	Tries           uint64 // of running solvers
+	Threads         int
+	Submits         uint64
+	Confirmed       uint64
+	Hashrate        float64 // 1 minute average
+	Hashrates       Rates
+	Solvers         []SolverStats
+	Workers         []WorkerStats // remote solvers of coordinator, their hashrate is included in Hashrates
+	NetworkHashrate float64
+	WinProbability  float64
+	Rewards         *big.Int   // confirmed, in INFINITY wei
+	GasSpent        *big.Int   // in $S wei
+	Balance         *big.Int   // submitter balance in $S wei, nil until fetched
+	NetProfit       *big.Float // in $S, nil if price is not configured
+	CurrentProblem  *Problem
+	LastSubmission  *Submission
+	Listening       bool // problem subscription is active
+	Paused          bool
+	PauseReason     string // unprofitable or manual
+}
+
+const (
+	statsInterval    = time.Minute / 10
+	balanceInterval  = time.Minute
+	contractInterval = time.Minute
+)
+
+type Miner struct {
+	cfg *Config
+
+	onProblem      []func(Problem)
+	onSolution     []func(Solution)
+	onSubmission   []func(Submission)
+	onConfirmation []func(Confirmation)
+
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	started time.Time
+
+	conn      *ethclient.Client
+	submitter *submitter.Submitter
+	pool      *solver.Pool
+	ledger    *accounting.Ledger
+	tracker   *tracker.Tracker
+	estimator *estimator.Estimator
+	metrics   *metrics.Metrics
+	notifier  *notify.Notifier
+	history   *history.Store
+	keys      *solutionlog.Log
+
+	stopMetrics func()
+	stopAPI     func()
+
+	// set by ServeWorkers
+	farmAddr  string
+	farmToken string
+	farmLocal bool
+	farm      *farm.Server
+
+	// nonce of problem, solutions are accepted for, owned by mining loop
+	nonce *big.Int
+
+	// counters, guarded by mu
+	problems       uint64
+	solutions      uint64
+	submits        uint64
+	confirmed      uint64
+	paused         bool // by profitability guard
+	pausedByUser   bool
+	balance        *big.Int
+	balanceAt      time.Time
+	lastSubmission *Submission
+	listening      bool
+
+	// notification state, guarded by mu
+	disconnectedAt    time.Time
+	disconnectAlerted bool
+	balanceLow        bool
+	contractPaused    bool
+	contractAt        time.Time
+}
+
+func New(cfg *Config) (*Miner, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	m := &Miner{
+		cfg:       cfg,
+		ledger:    accounting.NewLedger(cfg.Price),
+		tracker:   tracker.NewTracker(),
+		estimator: estimator.NewEstimator(),
+		logger:    slog.With("component", "miner"),
+	}
+	if cfg.AccountingFile != "" {
+		if err := m.ledger.Load(cfg.AccountingFile); err != nil {
+			return nil, fmt.Errorf("cant load accounting: %w", err)
+		}
+	}
+	m.metrics = metrics.New(m.solverStats)
+	if cfg.WebhookURL != "" {
+		notifier, err := notify.New(cfg.WebhookURL, cfg.WebhookTemplate, cfg.WebhookEvents)
+		if err != nil {
+			return nil, err
+		}
+		m.notifier = notifier
+	}
+	if cfg.HistoryFile != "" {
+		m.history = history.Open(cfg.HistoryFile)
+	}
+	if cfg.SolutionLog != "" {
+		keys, err := solutionlog.Open(cfg.SolutionLog, cfg.SolutionLogPassphrase, cfg.SolutionLogPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		m.keys = keys
+	}
+	return m, nil
+}
+
+// OnProblem, OnSolution, OnSubmission and OnConfirmation register event handlers.
+// They must be called before Start. Handlers are called from the mining loop
+// and must not block.
+func (m *Miner) OnProblem(fn func(Problem)) {
+	m.onProblem = append(m.onProblem, fn)
+}
+
+func (m *Miner) OnSolution(fn func(Solution)) {
+	m.onSolution = append(m.onSolution, fn)
+}
+
+func (m *Miner) OnSubmission(fn func(Submission)) {
+	m.onSubmission = append(m.onSubmission, fn)
+}
+
+func (m *Miner) OnConfirmation(fn func(Confirmation)) {
+	m.onConfirmation = append(m.onConfirmation, fn)
+}
+
+// ServeWorkers makes miner a coordinator of farm: workers connect to addr,
+// receive problems and send back solutions, which are submitted by miner.
+// Local solvers run only if local is set. It must be called before Start.
+func (m *Miner) ServeWorkers(addr string, token string, local bool) {
+	m.farmAddr = addr
+	m.farmToken = token
+	m.farmLocal = local
+}
+
+// Start checks network and contract, starts solvers and the mining loop.
+// Mining stops when ctx is canceled or Stop is called.
+func (m *Miner) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.done != nil {
+		m.mu.Unlock()
+		return errors.New("miner is already started")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	m.cancel = cancel
+	m.mu.Unlock()
+
+	if err := m.start(runCtx); err != nil {
+		cancel()
+		if m.conn != nil {
+			m.conn.Close()
+		}
+		m.history.Close()
+		closeCtx, cancelClose := context.WithTimeout(context.Background(), m.cfg.ShutdownTimeout)
+		m.notifier.Close(closeCtx)
+		cancelClose()
+		close(m.done)
+		return err
+	}
+	return nil
+}
+
+func (m *Miner) start(ctx context.Context) error {
+	cfg := m.cfg
+
+	conn, err := ethclient.DialContext(ctx, cfg.RPC)
+	if err != nil {
+		return internal.NewDialError(cfg.RPC, err)
+	}
+	m.conn = conn
+
+	state, err := preflight.Check(ctx, conn)
+	if state != nil {
+		m.logger.Info("Contract state", "state", state)
+	}
+	if errors.Is(err, preflight.ErrPaused) {
+		m.notifyPaused(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("preflight check failed: %w", err)
+	}
+
+	m.submitter, err = submitter.NewSubmitter(ctx, conn, cfg)
+	if err != nil {
+		return fmt.Errorf("cant create submitter: %w", err)
+	}
+	problems, listenerStates, err := listener.SubscribeToProblems(ctx, cfg.WS, cfg.CursorFile, cfg.Confirmations)
+	if err != nil {
+		return fmt.Errorf("cant subscribe for problems: %w", err)
+	}
+	m.setListening(true)
+
+	backfill, err := listener.Backfill(ctx, conn, m.submitter.Address, cfg.CursorFile, cfg.Confirmations)
+	if err != nil {
+		return fmt.Errorf("cant backfill missed logs: %w", err)
+	}
+	confirmed := uint64(0)
+	if backfill.FromBlock <= backfill.ToBlock {
+		m.logger.Info(
+			"Replayed missed blocks",
+			"from_block", backfill.FromBlock,
+			"to_block", backfill.ToBlock,
+			"problems", backfill.NumProblems,
+			"submissions", backfill.NumSubmissions,
+			"own_submissions", len(backfill.OwnSubmissions),
+			"rewards", accounting.ToEther(backfill.OwnRewards),
+		)
+		for _, submission := range backfill.OwnSubmissions {
+			// cursor lags behind confirmations, so submissions confirmed
+			// by the previous run are replayed too
+			transaction, err := m.history.Transaction(submission.Raw.TxHash)
+			if err != nil {
+				m.logger.Warn("Cant read history", "err", err)
+			}
+			if transaction != nil && transaction.Status == history.StatusConfirmed {
+				continue
+			}
+			m.logger.Info("Submission confirmed while miner was down", "tx", submission.Raw.TxHash, "block", submission.Raw.BlockNumber)
+			m.ledger.AddReward(submission.MinedAt, submission.Reward)
+			confirmed++
+			m.history.UpdateTransaction(submission.Raw.TxHash, func(transaction *history.Transaction) {
+				transaction.Status = history.StatusConfirmed
+				transaction.Reward = submission.Reward
+				transaction.Block = submission.Raw.BlockNumber
+			})
+		}
+	}
+	m.logHistory()
+	m.mu.Lock()
+	m.problems = backfill.NumProblems
+	m.submits = confirmed
+	m.confirmed = confirmed
+	m.mu.Unlock()
+	m.notifier.Start()
+
+	submitterBalance, err := m.submitter.GetBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("cant get submitter balance: %w", err)
+	}
+	m.setBalance(submitterBalance)
+	m.logger.Info("Submitter, ensure that it has enough funds", "address", m.submitter.Address, "balance", accounting.ToEther(submitterBalance))
+
+	solutionCh := make(chan solver.Solution, runtime.NumCPU())
+	pool := solver.NewPool(ctx, cfg.ReserveCores, cfg.CPUAffinity, solutionCh)
+	if m.farmAddr == "" || m.farmLocal {
+		solvers, err := pool.Resize(cfg.Threads)
+		if err != nil {
+			return fmt.Errorf("cant start solvers: %w", err)
+		}
+		if cfg.CPUAffinity {
+			m.logger.Info("Started solvers", "threads", len(solvers), "cpus", pool.CPUs())
+		} else {
+			m.logger.Info("Started solvers", "threads", len(solvers))
+		}
+	}
+	if m.farmAddr != "" {
+		m.farm, err = farm.Listen(ctx, m.farmAddr, m.farmToken, solutionCh)
+		if err != nil {
+			return fmt.Errorf("cant serve workers: %w", err)
+		}
+		m.logger.Info("Serving workers", "addr", m.farmAddr)
+	}
+
+	m.mu.Lock()
+	m.pool = pool
+	m.started = time.Now()
+	m.mu.Unlock()
+	// apart from mining loop, which can be blocked by submission
+	go pool.SampleEvery(ctx, hashrate.SampleInterval)
+
+	go func() {
+		currentProblem, err := listener.CurrentProblem(ctx, conn)
+		if err != nil {
+			m.logger.Warn("Cant get current problem", "err", err)
+			return
+		}
+		select {
+		case problems <- *currentProblem:
+		case <-ctx.Done():
+		}
+	}()
+
+	// in-flight submissions get ShutdownTimeout after mining is stopped
+	drainCtx, cancelDrain := context.WithCancel(context.WithoutCancel(ctx))
+	context.AfterFunc(ctx, func() {
+		time.AfterFunc(cfg.ShutdownTimeout, cancelDrain)
+	})
+
+	if cfg.MetricsAddr != "" {
+		m.stopMetrics, err = m.metrics.Serve(cfg.MetricsAddr)
+		if err != nil {
+			return fmt.Errorf("cant serve metrics: %w", err)
+		}
+		m.logger.Info("Serving metrics", "addr", cfg.MetricsAddr)
+	}
+	if cfg.APIAddr != "" {
+		m.stopAPI, err = api.Serve(cfg.APIAddr, cfg.APIToken, apiController{m})
+		if err != nil {
+			if m.stopMetrics != nil {
+				m.stopMetrics()
+			}
+			return fmt.Errorf("cant serve api: %w", err)
+		}
+		m.logger.Info("Serving API", "addr", cfg.APIAddr)
+	}
+
+	go m.run(ctx, drainCtx, problems, listenerStates, solutionCh)
+	return nil
+}
+
+// Stop stops mining and waits for in-flight submissions (up to ShutdownTimeout).
+func (m *Miner) Stop() {
+	m.mu.Lock()
+	done := m.done
+	cancel := m.cancel
+	m.mu.Unlock()
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Done is closed when the mining loop exits.
+func (m *Miner) Done() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.done
+}
+
+// MetricsHandler serves miner metrics in Prometheus text format,
+// for services which expose their own /metrics endpoint.
+func (m *Miner) MetricsHandler() http.Handler {
+	return m.metrics.Handler()
+}
+
+func (m *Miner) Stats() Stats {
+	m.mu.Lock()
+	stats := Stats{
+		StartedAt:      m.started,
+		Problems:       m.problems,
+		Solutions:      m.solutions,
+		Submits:        m.submits,
+		Confirmed:      m.confirmed,
+		Paused:         m.paused || m.pausedByUser,
+		Balance:        m.balance,
+		LastSubmission: m.lastSubmission,
+		Listening:      m.listening,
+	}
+	if m.pausedByUser {
+		stats.PauseReason = "manual"
+	} else if m.paused {
+		stats.PauseReason = "unprofitable"
+	}
+	m.mu.Unlock()
+
+	stats.Solvers = m.solverStats()
+	for _, solver := range stats.Solvers {
+		stats.Threads += 1
+		stats.Tries += solver.Tries
+		stats.Hashrates = stats.Hashrates.Add(solver.Hashrate)
+	}
+	stats.Workers = m.farm.Workers()
+	for _, worker := range stats.Workers {
+		stats.Hashrates = stats.Hashrates.Add(worker.Hashrate)
+	}
+	stats.Hashrate = stats.Hashrates.Rate1m
+	stats.NetworkHashrate = m.estimator.NetworkHashrate()
+	stats.WinProbability = m.estimator.WinProbability(stats.Hashrate)
+
+	session := m.ledger.Session()
+	stats.Rewards = session.Rewards
+	stats.GasSpent = session.GasSpent
+	stats.NetProfit = m.ledger.NetProfit(session)
+	stats.CurrentProblem = m.tracker.Current()
+	return stats
+}
+
+func (m *Miner) solverStats() []metrics.SolverStats {
+	m.mu.Lock()
+	pool := m.pool
+	m.mu.Unlock()
+	if pool == nil {
+		return nil
+	}
+
+	solvers := pool.Solvers()
+	stats := make([]metrics.SolverStats, len(solvers))
+	for i, solver := range solvers {
+		stats[i] = metrics.SolverStats{
+			Tries:     solver.NumTries.Load(),
+			Solutions: solver.NumSolutions.Load(),
+			Hashrate:  solver.Hashrate.Rates(),
+		}
+	}
+	return stats
+}
+
+func (m *Miner) setListening(listening bool) {
+	m.mu.Lock()
+	m.listening = listening
+	if !listening {
+		m.disconnectedAt = time.Now()
+		m.disconnectAlerted = false
+	}
+	m.mu.Unlock()
+	if listening {
+		m.metrics.ListenerConnected.Set(1)
+	} else {
+		m.metrics.ListenerConnected.Set(0)
+	}
+}
+
+func (m *Miner) setBalance(balance *big.Int) {
+	ether := accounting.ToEther(balance)
+	low := m.cfg.LowBalance != nil && ether.Cmp(m.cfg.LowBalance) < 0
+
+	m.mu.Lock()
+	m.balance = balance
+	m.balanceAt = time.Now()
+	wasLow := m.balanceLow
+	m.balanceLow = low
+	m.mu.Unlock()
+	value, _ := ether.Float64()
+	m.metrics.Balance.Set(value)
+
+	if low && !wasLow {
+		m.logger.Warn("Submitter balance is low", "balance", ether)
+		m.notify(notify.BalanceLow, "Submitter balance is low", map[string]string{
+			"balance":   ether.Text('f', 6),
+			"threshold": m.cfg.LowBalance.Text('f', 6),
+		})
+	}
+}
+
+func (m *Miner) notify(eventType string, message string, data map[string]string) {
+	event := notify.Event{Type: eventType, Message: message, Data: data}
+	if m.submitter != nil {
+		event.Miner = m.submitter.Address.Hex()
+	}
+	m.notifier.Notify(event)
+}
+
+// notifyPaused sends notification synchronously, because miner doesn't start.
+func (m *Miner) notifyPaused(ctx context.Context) {
+	event := notify.Event{Type: notify.ContractPaused, Message: "PoW contract is paused, miner can't start"}
+	if err := m.notifier.Send(ctx, event); err != nil {
+		m.logger.Warn("Cant send notification", "type", event.Type, "err", err)
+	}
+}
+
+// checkListener notifies once, when listener is disconnected longer than ListenerAlertAfter.
+func (m *Miner) checkListener() {
+	m.mu.Lock()
+	alert := !m.listening && !m.disconnectAlerted && time.Since(m.disconnectedAt) > m.cfg.ListenerAlertAfter
+	if alert {
+		m.disconnectAlerted = true
+	}
+	disconnectedAt := m.disconnectedAt
+	m.mu.Unlock()
+	if alert {
+		m.notify(notify.ListenerDisconnected, "Problem listener is disconnected", map[string]string{
+			"since": disconnectedAt.Format(time.RFC3339),
+		})
+	}
+}
+
+func (m *Miner) checkContract(ctx context.Context) {
+	m.mu.Lock()
+	contractAt := m.contractAt
+	m.mu.Unlock()
+	if time.Since(contractAt) < contractInterval {
+		return
+	}
+	paused, err := preflight.Paused(ctx, m.conn)
+	if err != nil {
+		m.logger.Warn("Cant check contract state", "err", err)
+		return
+	}
+	m.setContractPaused(paused)
+}
+
+func (m *Miner) setContractPaused(paused bool) {
+	m.mu.Lock()
+	wasPaused := m.contractPaused
+	m.contractPaused = paused
+	m.contractAt = time.Now()
+	m.mu.Unlock()
+
+	if paused && !wasPaused {
+		m.logger.Warn("PoW contract is paused")
+		m.notify(notify.ContractPaused, "PoW contract is paused", nil)
+	} else if !paused && wasPaused {
+		m.logger.Info("PoW contract is unpaused")
+	}
+}
+
+func (m *Miner) updateBalance(ctx context.Context) {
+	m.mu.Lock()
+	balanceAt := m.balanceAt
+	m.mu.Unlock()
+	if time.Since(balanceAt) < balanceInterval {
+		return
+	}
+	balance, err := m.submitter.GetBalance(ctx)
+	if err != nil {
+		m.logger.Warn("Cant get submitter balance", "err", err)
+		return
+	}
+	m.setBalance(balance)
+}
+
+// observeLatency measures delay between problem block and now.
+func (m *Miner) observeLatency(ctx context.Context, blockNumber uint64, seenAt time.Time) {
+	minedAt, err := listener.BlockTime(ctx, m.conn, blockNumber)
+	if err != nil {
+		m.logger.Debug("Cant get problem block", "block", blockNumber, "err", err)
+		return
+	}
+	latency := seenAt.Sub(minedAt)
+	m.metrics.ProblemLatency.Observe(max(latency.Seconds(), 0))
+}
+
+// logHistory logs totals of previous runs.
+func (m *Miner) logHistory() {
+	if m.history == nil {
+		return
+	}
+	summary, err := m.history.Summary(history.Filter{})
+	if err != nil {
+		m.logger.Warn("Cant read history", "err", err)
+		return
+	}
+	m.logger.Info(
+		"History",
+		"since", summary.FirstSeen.Format(time.DateTime),
+		"problems", summary.Problems,
+		"solutions", summary.Solutions,
+		"transactions", summary.Transactions,
+		"confirmed", summary.Statuses[history.StatusConfirmed],
+		"rewards", accounting.ToEther(summary.Rewards),
+		"fees", accounting.ToEther(summary.Fees),
+	)
+}
+
+func (m *Miner) report() {
+	stats := m.Stats()
+
+	attrs := []any{
+		"problems", stats.Problems,
+		"solutions", stats.Solutions,
+		"submits", stats.Submits,
+		"confirmed", stats.Confirmed,
+		"rewards", accounting.ToEther(stats.Rewards),
+		"gas", accounting.ToEther(stats.GasSpent),
+	}
+	if stats.NetProfit != nil {
+		attrs = append(attrs, "net_profit", stats.NetProfit)
+	}
+	attrs = append(
+		attrs,
+		"hashrate_10s", utils.FormatHashrate(stats.Hashrates.Rate10s),
+		"hashrate_1m", utils.FormatHashrate(stats.Hashrates.Rate1m),
+		"hashrate_15m", utils.FormatHashrate(stats.Hashrates.Rate15m),
+	)
+	m.logger.Info("Stats", attrs...)
+	if stats.NetworkHashrate > 0 {
+		m.logger.Info(
+			"Network",
+			"hashrate", utils.FormatHashrate(stats.NetworkHashrate),
+			"win_probability", stats.WinProbability,
+			"expected_time_to_solve", m.estimator.ExpectedTimeToSolve(stats.Hashrate).Round(time.Second),
+		)
+	}
+	if m.cfg.AccountingFile != "" {
+		if err := m.ledger.Export(m.cfg.AccountingFile); err != nil {
+			m.logger.Warn("Cant export accounting", "err", err)
+		}
+	}
+}
+
+func (m *Miner) checkConfirmations(ctx context.Context) {
+	confirmed, reorged, err := m.submitter.CheckConfirmations(ctx)
+	if err != nil {
+		m.logger.Warn("Cant check confirmations", "err", err)
+	}
+	for _, submission := range confirmed {
+		m.mu.Lock()
+		m.confirmed += 1
+		m.mu.Unlock()
+		m.ledger.AddReward(time.Now(), submission.Reward)
+		m.metrics.Submissions.WithLabelValues("confirmed", "").Inc()
+		m.logger.Info(
+			"Submission confirmed",
+			"tx", submission.TxHash,
+			"block", submission.BlockNumber,
+			"reward", accounting.ToEther(submission.Reward),
+		)
+		// submission is updated by submitter, history is written later by its writer
+		reward, block := submission.Reward, submission.BlockNumber
+		m.history.UpdateTransaction(submission.TxHash, func(transaction *history.Transaction) {
+			transaction.Status = history.StatusConfirmed
+			transaction.Reward = reward
+			transaction.Block = block
+		})
+		m.notify(notify.SubmissionConfirmed, "Submission confirmed", map[string]string{
+			"tx":     submission.TxHash.Hex(),
+			"block":  strconv.FormatUint(submission.BlockNumber, 10),
+			"reward": accounting.ToEther(submission.Reward).Text('f', 6),
+		})
+		for _, fn := range m.onConfirmation {
+			fn(*submission)
+		}
+	}
+	for _, submission := range reorged {
+		m.logger.Warn("Submission was reorged out, reward is unconfirmed", "tx", submission.TxHash, "block", submission.BlockNumber)
+		m.metrics.Submissions.WithLabelValues("unconfirmed", "reorg").Inc()
+		m.history.UpdateTransaction(submission.TxHash, func(transaction *history.Transaction) {
+			transaction.Status = history.StatusUnconfirmed
+		})
+		for _, fn := range m.onConfirmation {
+			fn(*submission)
+		}
+	}
+}
+
+// applyPause pauses solvers if mining is paused by profitability guard or by user.
+func (m *Miner) applyPause() {
+	m.mu.Lock()
+	paused := m.paused || m.pausedByUser
+	pool := m.pool
+	m.mu.Unlock()
+	if pool == nil {
+		return
+	}
+	for _, solver := range pool.Solvers() {
+		if paused {
+			solver.Pause()
+		} else {
+			solver.Resume()
+		}
+	}
+}
+
+func (m *Miner) setPaused(paused bool) {
+	m.mu.Lock()
+	m.paused = paused
+	m.mu.Unlock()
+	m.applyPause()
+}
+
+// Pause stops hashing until Resume. Problems and submissions are still tracked.
+func (m *Miner) Pause() {
+	m.mu.Lock()
+	m.pausedByUser = true
+	m.mu.Unlock()
+	m.applyPause()
+	m.logger.Info("Mining is paused")
+}
+
+func (m *Miner) Resume() {
+	m.mu.Lock()
+	m.pausedByUser = false
+	m.mu.Unlock()
+	m.applyPause()
+	m.logger.Info("Mining is resumed")
+}
+
+// SetThreads changes number of running solvers, 0 - one per available cpu.
+func (m *Miner) SetThreads(threads int) error {
+	m.mu.Lock()
+	pool := m.pool
+	m.mu.Unlock()
+	if pool == nil {
+		return errors.New("miner is not started")
+	}
+
+	started, err := pool.Resize(threads)
+	if err != nil {
+		return err
+	}
+	m.applyPause()
+	if current := m.tracker.Current(); current != nil {
+		solver.Dispatch(started, current.PoWNewProblem)
+	}
+	m.logger.Info("Changed number of solvers", "threads", len(pool.Solvers()))
+	return nil
+}
+
+// checkProfitability also refreshes reward, which submitter checks solutions with.
+func (m *Miner) checkProfitability(ctx context.Context) {
+	if m.submitter.GuardMode() == submitter.GuardOff {
+		return
+	}
+
+	profitability, ok, err := m.submitter.CheckProfitability(ctx)
+	if m.submitter.GuardMode() != submitter.GuardPause {
+		if err != nil {
+			m.logger.Warn("Cant check profitability", "err", err)
+		}
+		return
+	}
+	m.mu.Lock()
+	paused := m.paused
+	m.mu.Unlock()
+	if err != nil {
+		m.logger.Warn("Cant check profitability", "err", err)
+	} else if !ok && !paused {
+		m.logger.Info("Pausing mining, submission is unprofitable", "profit", profitability.Profit.Text('f', 6))
+		m.setPaused(true)
+	} else if ok && paused {
+		m.logger.Info("Resuming mining, submission is profitable", "profit", profitability.Profit.Text('f', 6))
+		m.setPaused(false)
+	}
+}
+
+func (m *Miner) handleProblem(ctx context.Context, problem PoW.PoWNewProblem) {
+	if problem.Raw.Removed {
+		if m.tracker.Remove(problem) {
+			m.logger.Info("Current problem was reorged out, waiting for canonical one", "nonce", problem.Nonce, "block", problem.Raw.BlockNumber)
+			m.nonce = big.NewInt(-1)
+		}
+		return
+	}
+	if !m.tracker.Update(problem, problem.Raw.BlockNumber) {
+		m.logger.Debug("Skipping stale problem", "nonce", problem.Nonce, "block", problem.Raw.BlockNumber)
+		return
+	}
+
+	m.mu.Lock()
+	m.problems += 1
+	m.mu.Unlock()
+	m.metrics.Problems.Inc()
+	m.nonce = problem.Nonce
+	// only problems from logs have real publish time
+	if problem.Raw.BlockHash != (common.Hash{}) {
+		m.estimator.ObserveProblem(time.Now(), problem.Difficulty)
+		go m.observeLatency(ctx, problem.Raw.BlockNumber, time.Now())
+	}
+	m.logger.Info("Got new problem", "nonce", problem.Nonce, "difficulty", common.BigToAddress(problem.Difficulty), "block", problem.Raw.BlockNumber)
+	solver.Dispatch(m.pool.Solvers(), problem)
+	m.farm.SetProblem(problem)
+	current := m.tracker.Current()
+	m.history.AddProblem(history.Problem{
+		Nonce:      problem.Nonce,
+		Difficulty: problem.Difficulty,
+		Block:      current.BlockNumber,
+		SeenAt:     current.SeenAt,
+	})
+	for _, fn := range m.onProblem {
+		fn(*current)
+	}
+}
+
+func (m *Miner) handleSolution(ctx context.Context, solution solver.Solution) *SubmitResult {
+	privateKeyAB, err := utils.EcAdd(solution.PrivateKeyA, solution.PrivateKeyB)
+	if err != nil {
+		m.logger.Warn("Invalid solution", "nonce", &solution.Nonce, "err", err)
+		return nil
+	}
+	addressAB := crypto.PubkeyToAddress(privateKeyAB.PublicKey)
+	err = m.keys.Write(solutionlog.Record{
+		AddressAB:    addressAB,
+		PrivateKeyAB: privateKeyAB,
+		Nonce:        &solution.Nonce,
+		PrivateKeyB:  &solution.PrivateKeyB,
+	})
+	if err != nil {
+		m.logger.Error("Cant write solution log", "err", err)
+	}
+	foundAt := time.Now()
+	record := history.Solution{Nonce: &solution.Nonce, AddressAB: addressAB, FoundAt: foundAt}
+	if current := m.tracker.Current(); current != nil && current.Nonce.Cmp(&solution.Nonce) == 0 {
+		record.SolveTime = foundAt.Sub(current.SeenAt)
+	}
+	for _, fn := range m.onSolution {
+		fn(Solution{
+			Nonce:     &solution.Nonce,
+			AddressAB: addressAB,
+			FoundAt:   foundAt,
+		})
+	}
+	m.notify(notify.SolutionFound, "Solution found", map[string]string{
+		"nonce":      solution.Nonce.String(),
+		"address_ab": addressAB.Hex(),
+	})
+
+	sentAt := time.Now()
+	result, err := m.submitter.Submit(ctx, solution.PrivateKeyB, *privateKeyAB)
+	if result != nil {
+		m.metrics.Submissions.WithLabelValues("sent", "").Inc()
+	}
+	if err == nil {
+		m.mu.Lock()
+		m.submits += 1
+		m.mu.Unlock()
+		if result.Reverted {
+			m.metrics.Submissions.WithLabelValues("reverted", result.RevertReason).Inc()
+			m.logger.Warn("Submission reverted", "nonce", &solution.Nonce, "tx", result.TxHash, "reason", result.RevertReason)
+			m.notify(notify.SubmissionReverted, "Submission reverted", map[string]string{
+				"nonce":  solution.Nonce.String(),
+				"tx":     result.TxHash.Hex(),
+				"reason": result.RevertReason,
+			})
+			if result.RevertReason == "EnforcedPause" {
+				m.setContractPaused(true)
+			}
+		} else {
+			m.logger.Info("Submission mined", "nonce", &solution.Nonce, "tx", result.TxHash, "winner", result.Submission != nil)
+		}
+	} else if errors.Is(err, submitter.ErrUnprofitable) {
+		m.logger.Info("Skipping submission", "nonce", &solution.Nonce, "err", err)
+		m.metrics.Submissions.WithLabelValues("skipped", "unprofitable").Inc()
+	} else {
+		m.logger.Warn("Submission failed", "nonce", &solution.Nonce, "err", err)
+		reason := "send"
+		if result != nil {
+			reason = "receipt"
+		}
+		m.metrics.Submissions.WithLabelValues("failed", reason).Inc()
+	}
+	submission := Submission{Nonce: &solution.Nonce, Result: result, Err: err, At: time.Now()}
+	m.history.AddSolution(record)
+	if result != nil {
+		sent := history.Transaction{
+			Nonce:        &solution.Nonce,
+			TxNonce:      result.Nonce,
+			GasPrice:     result.GasPrice,
+			Fee:          result.GasCost,
+			Status:       transactionStatus(result, err),
+			RevertReason: result.RevertReason,
+			SentAt:       sentAt,
+		}
+		if err != nil {
+			sent.Error = err.Error()
+		}
+		m.history.UpdateTransaction(result.TxHash, func(transaction *history.Transaction) {
+			transaction.Nonce = sent.Nonce
+			transaction.TxNonce = sent.TxNonce
+			transaction.GasPrice = sent.GasPrice
+			transaction.Fee = sent.Fee
+			transaction.Status = sent.Status
+			transaction.RevertReason = sent.RevertReason
+			transaction.Error = sent.Error
+			transaction.SentAt = sent.SentAt
+		})
+	}
+	m.mu.Lock()
+	m.lastSubmission = &submission
+	m.mu.Unlock()
+	for _, fn := range m.onSubmission {
+		fn(submission)
+	}
+	if result != nil {
+		m.ledger.AddGas(time.Now(), result.GasCost, result.Reverted)
+		if result.GasCost != nil {
+			gas, _ := accounting.ToEther(result.GasCost).Float64()
+			m.metrics.GasSpent.Add(gas)
+			m.mu.Lock()
+			m.balanceAt = time.Time{}
+			m.mu.Unlock()
+		}
+	}
+	return result
+}
+
+func transactionStatus(result *SubmitResult, err error) string {
+	switch {
+	case err != nil:
+		return history.StatusFailed
+	case result.Reverted:
+		return history.StatusReverted
+	case result.Submission != nil:
+		return history.StatusWon
+	}
+	return history.StatusLost
+}
+
+func (m *Miner) run(ctx context.Context, drainCtx context.Context, problems <-chan PoW.PoWNewProblem, listenerStates <-chan error, solutionCh <-chan solver.Solution) {
+	defer close(m.done)
+	defer func() {
+		if m.stopMetrics != nil {
+			m.stopMetrics()
+		}
+		if m.stopAPI != nil {
+			m.stopAPI()
+		}
+		m.conn.Close()
+		m.history.Close()
+	}()
+
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.checkConfirmations(drainCtx)
+			m.report()
+			m.notifier.Close(drainCtx)
+			return
+		case problem := <-problems:
+			m.handleProblem(ctx, problem)
+		case err := <-listenerStates:
+			// listener reconnects by itself, keep mining last problem meanwhile
+			m.setListening(err == nil)
+		case solution := <-solutionCh:
+			m.mu.Lock()
+			m.solutions += 1
+			m.mu.Unlock()
+			if ctx.Err() != nil || m.nonce == nil || solution.Nonce.Cmp(m.nonce) != 0 {
+				continue
+			}
+			result := m.handleSolution(drainCtx, solution)
+			if result != nil && result.IsNextProblem {
+				m.nonce = big.NewInt(-1)
+			}
+		case <-ticker.C:
+			m.checkConfirmations(drainCtx)
+			m.checkProfitability(ctx)
+			m.updateBalance(ctx)
+			m.checkContract(ctx)
+			m.checkListener()
+			m.report()
+		}
+	}
+}
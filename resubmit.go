@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/listener"
+	"infinity/miner/internal/preflight"
+	"infinity/miner/internal/solutionlog"
+	"infinity/miner/internal/solver"
+	submitterpkg "infinity/miner/internal/submitter"
+	"infinity/miner/internal/utils"
+	"log"
+)
+
+// runResubmit submits logged solutions of the current problem, e.g. after miner crashed
+// before its submission was mined. Only one solution can win, so it stops after the
+// first sent transaction.
+func runResubmit(cfg *config.Loaded, args []string) {
+	flags := flag.NewFlagSet("resubmit", flag.ExitOnError)
+	file := flags.String("file", cfg.SolutionLog, "solution log")
+	keyFile := flags.String("key-file", "", "file with private key of solution_log_public_key, see solutions keygen")
+	dryRun := flags.Bool("dry-run", false, "only print solutions, which would be submitted")
+	flags.Parse(args)
+
+	if _, err := parsePrivateKey(cfg.PrivateKey); err != nil {
+		log.Fatal("invalid private_key: ", err)
+	}
+
+	var records []*solutionlog.Record
+	var legacy int
+	failed, err := readSolutionLog(cfg, *file, *keyFile, func(line string) {
+		record, err := solutionlog.ParseRecord(line)
+		if err != nil {
+			log.Printf("Skipping record: %s", err)
+			return
+		}
+		if record.Nonce == nil {
+			legacy++
+			return
+		}
+		records = append(records, record)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if failed > 0 {
+		log.Printf("%d records could not be decrypted, set solution_log_passphrase or -key-file", failed)
+	}
+	if legacy > 0 {
+		log.Printf("%d records have no problem nonce and key B, they were written by older version", legacy)
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	conn := dial(ctx, cfg.Config)
+	if _, err := preflight.Check(ctx, conn); err != nil {
+		log.Fatal(err)
+	}
+	problem, err := listener.CurrentProblem(ctx, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	privateKeyA, err := utils.ParsePrivateKey(*problem.PrivateKeyA)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var candidates []*solutionlog.Record
+	for _, record := range records {
+		if record.Nonce.Cmp(problem.Nonce) != 0 {
+			continue
+		}
+		privateKeyAB, ok, err := solver.Verify(*privateKeyA, *record.PrivateKeyB, problem.Difficulty)
+		if err != nil || !ok || privateKeyAB.D.Cmp(record.PrivateKeyAB.D) != 0 {
+			log.Printf("Solution %s doesn't solve current problem %s", record.AddressAB, problem.Nonce)
+			continue
+		}
+		candidates = append(candidates, record)
+	}
+	log.Printf("Current problem %s: %d of %d logged solutions are still valid", problem.Nonce, len(candidates), len(records))
+	if len(candidates) == 0 || *dryRun {
+		for _, record := range candidates {
+			log.Printf("Would submit solution %s", record.AddressAB)
+		}
+		return
+	}
+
+	submitter, err := submitterpkg.NewSubmitter(ctx, conn, cfg.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	record := candidates[0]
+	log.Printf("Submitting solution %s", record.AddressAB)
+	result, err := submitter.Submit(ctx, *record.PrivateKeyB, *record.PrivateKeyAB)
+	if result != nil {
+		log.Printf("Transaction %s, reverted: %t", result.TxHash, result.Reverted)
+		if result.Reverted {
+			log.Printf("Revert reason: %s", result.RevertReason)
+		}
+		if result.Submission != nil {
+			log.Printf("Reward: %f INFINITY", accounting.ToEther(result.Submission.Reward))
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// commandContext is canceled on SIGINT/SIGTERM, for short-lived commands.
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// shutdownContext is canceled on the first SIGINT/SIGTERM, miner then waits
+// up to drainTimeout for in-flight submissions. Second signal exits immediately.
+func shutdownContext(drainTimeout time.Duration) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		log.Printf("Shutting down, waiting up to %s for in-flight submissions (repeat signal to force)", drainTimeout)
+		cancel()
+
+		<-signals
+		log.Printf("Forced exit")
+		os.Exit(1)
+	}()
+
+	return ctx
+}
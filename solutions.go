@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/solutionlog"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+)
+
+const solutionsUsage = "usage: miner [flags] solutions decrypt [-file path] [-key-file path] | solutions keygen [-key-file path]"
+
+func runSolutions(cfg *config.Loaded, args []string) {
+	if len(args) == 0 {
+		log.Fatal(solutionsUsage)
+	}
+	switch args[0] {
+	case "decrypt":
+		runSolutionsDecrypt(cfg, args[1:])
+	case "keygen":
+		runSolutionsKeygen(args[1:])
+	default:
+		log.Fatal(solutionsUsage)
+	}
+}
+
+// runSolutionsDecrypt prints plain records of solution log, passphrase is taken from solution_log_passphrase.
+func runSolutionsDecrypt(cfg *config.Loaded, args []string) {
+	flags := flag.NewFlagSet("solutions decrypt", flag.ExitOnError)
+	file := flags.String("file", cfg.SolutionLog, "solution log")
+	keyFile := flags.String("key-file", "", "file with private key of solution_log_public_key, see solutions keygen")
+	flags.Parse(args)
+
+	failed, err := readSolutionLog(cfg, *file, *keyFile, func(record string) {
+		fmt.Println(record)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if failed > 0 {
+		log.Fatalf("%d records could not be decrypted", failed)
+	}
+}
+
+// readSolutionLog calls fn with every decrypted record of file and returns number of records,
+// which could not be decrypted. Passphrase is taken from solution_log_passphrase.
+func readSolutionLog(cfg *config.Loaded, file string, keyFile string, fn func(record string)) (int, error) {
+	var privateKey string
+	if keyFile != "" {
+		raw, err := os.ReadFile(keyFile)
+		if err != nil {
+			return 0, err
+		}
+		privateKey = strings.TrimSpace(string(raw))
+	}
+	decrypter, err := solutionlog.NewDecrypter(cfg.SolutionLogPassphrase, privateKey)
+	if err != nil {
+		return 0, fmt.Errorf("private key: %w", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	failed := 0
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		record, err := decrypter.Decrypt(line)
+		if err != nil {
+			log.Printf("%s:%d: %s", file, n, err)
+			failed++
+			continue
+		}
+		fn(record)
+	}
+	return failed, scanner.Err()
+}
+
+// runSolutionsKeygen writes private key to key file and prints public key for solution_log_public_key.
+func runSolutionsKeygen(args []string) {
+	flags := flag.NewFlagSet("solutions keygen", flag.ExitOnError)
+	keyFile := flags.String("key-file", "solution.key", "file to write private key to, keep it off the mining host")
+	flags.Parse(args)
+
+	publicKey, privateKey, err := solutionlog.GenerateKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.OpenFile(*keyFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		log.Fatalf("%s already exists", *keyFile)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := f.WriteString(privateKey + "\n"); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Private key is written to %s", *keyFile)
+	fmt.Println(publicKey)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/stats"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func runStats(cfg *config.Loaded, args []string) {
+	if len(args) == 0 || args[0] != "network" {
+		log.Fatal("usage: miner [flags] stats network [-from block] [-to block] [-blocks n]")
+	}
+
+	flags := flag.NewFlagSet("stats network", flag.ExitOnError)
+	fromBlock := flags.Uint64("from", 0, "first block to scan (default: to - blocks + 1)")
+	toBlock := flags.Uint64("to", 0, "last block to scan (default: latest)")
+	numBlocks := flags.Uint64("blocks", 10_000, "number of blocks to scan, if -from is not set")
+	flags.Parse(args[1:])
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	conn := dial(ctx, cfg.Config)
+	if *toBlock == 0 {
+		head, err := conn.BlockNumber(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*toBlock = head
+	}
+	if *fromBlock == 0 && *numBlocks <= *toBlock {
+		*fromBlock = *toBlock - *numBlocks + 1
+	}
+
+	network, err := stats.Network(ctx, conn, *fromBlock, *toBlock)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Blocks %d-%d: %d problems, %d submissions, %f INFINITY rewarded, avg time to solve %s\n\n",
+		network.FromBlock,
+		network.ToBlock,
+		network.Problems,
+		network.Submissions,
+		accounting.ToEther(network.Rewards),
+		network.AvgTimeToSolve(),
+	)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MINER\tWINS\tSHARE\tREWARDS\tAVG TIME TO SOLVE\tTAGS")
+	for _, miner := range network.Miners {
+		share := new(big.Float)
+		if network.Rewards.Sign() > 0 {
+			share.Quo(new(big.Float).SetInt(miner.Rewards), new(big.Float).SetInt(network.Rewards))
+			share.Mul(share, big.NewFloat(100))
+		}
+
+		tags := make([]string, 0, len(miner.Tags))
+		for tag, n := range miner.Tags {
+			if tag == "" {
+				tag = "<none>"
+			}
+			tags = append(tags, fmt.Sprintf("%s(%d)", tag, n))
+		}
+		sort.Strings(tags)
+
+		fmt.Fprintf(w, "%s\t%d\t%.2f%%\t%f\t%s\t%s\n",
+			miner.Miner,
+			miner.Wins,
+			share,
+			accounting.ToEther(miner.Rewards),
+			miner.AvgTimeToSolve(),
+			strings.Join(tags, " "),
+		)
+	}
+	w.Flush()
+}
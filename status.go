@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/listener"
+	"infinity/miner/internal/preflight"
+	"infinity/miner/internal/utils"
+	"log"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func runStatus(cfg *config.Loaded, args []string) {
+	if len(args) > 0 {
+		log.Fatal("usage: miner [flags] status")
+	}
+	ctx, stop := commandContext()
+	defer stop()
+
+	conn := dial(ctx, cfg.Config)
+	printf := func(format string, a ...any) { fmt.Printf(format+"\n", a...) }
+
+	state, err := preflight.ReadContractState(ctx, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	state.Print(printf)
+
+	problem, err := listener.CurrentProblem(ctx, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printf("Current problem: nonce %s, difficulty %s, block %d", problem.Nonce, common.BigToAddress(problem.Difficulty), problem.Raw.BlockNumber)
+
+	if cfg.PrivateKey == "" {
+		return
+	}
+	privateKey, err := utils.HexToPrivateKey(cfg.PrivateKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	balance, err := conn.BalanceAt(ctx, address, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printf("Submitter address: %s", address)
+	printf("Submitter balance: %f $S", accounting.ToEther(balance))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"flag"
+	"fmt"
+	"infinity/miner/internal/accounting"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/contracts/PoW"
+	"infinity/miner/internal/listener"
+	"infinity/miner/internal/solver"
+	submitterpkg "infinity/miner/internal/submitter"
+	"infinity/miner/internal/utils"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func parsePrivateKey(raw string) (*ecdsa.PrivateKey, error) {
+	if raw == "" {
+		return nil, errors.New("private key is not set")
+	}
+	return utils.HexToPrivateKey(raw)
+}
+
+// problemFromFlags returns problem given with -private-key-a and -difficulty,
+// or current problem from chain if they are not set.
+func problemFromFlags(ctx context.Context, cfg *config.Loaded, privateKeyA string, difficulty string) (*PoW.PoWNewProblem, error) {
+	if privateKeyA == "" && difficulty == "" {
+		return listener.CurrentProblem(ctx, dial(ctx, cfg.Config))
+	}
+	if privateKeyA == "" || difficulty == "" {
+		return nil, errors.New("set both -private-key-a and -difficulty or none of them")
+	}
+
+	rawPrivateKeyA, ok := new(big.Int).SetString(strings.TrimPrefix(privateKeyA, "0x"), 16)
+	if !ok {
+		return nil, errors.New("invalid -private-key-a, expected hex")
+	}
+	rawDifficulty, ok := new(big.Int).SetString(strings.TrimPrefix(difficulty, "0x"), 16)
+	if !ok {
+		return nil, errors.New("invalid -difficulty, expected hex")
+	}
+	return &PoW.PoWNewProblem{PrivateKeyA: rawPrivateKeyA, Difficulty: rawDifficulty}, nil
+}
+
+func runVerify(cfg *config.Loaded, args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	key := flags.String("key", "", "private key B (hex)")
+	privateKeyA := flags.String("private-key-a", "", "problem private key A (0x hex), default current problem")
+	difficulty := flags.String("difficulty", "", "problem difficulty (0x hex), default current problem")
+	flags.Parse(args)
+
+	privateKeyB, err := parsePrivateKey(*key)
+	if err != nil {
+		log.Fatal("invalid -key: ", err)
+	}
+	ctx, stop := commandContext()
+	defer stop()
+
+	problem, err := problemFromFlags(ctx, cfg, *privateKeyA, *difficulty)
+	if err != nil {
+		log.Fatal(err)
+	}
+	parsedPrivateKeyA, err := utils.ParsePrivateKey(*problem.PrivateKeyA)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	privateKeyAB, ok, err := solver.Verify(*parsedPrivateKeyA, *privateKeyB, problem.Difficulty)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if problem.Nonce != nil {
+		fmt.Printf("Problem nonce: %s\n", problem.Nonce)
+	}
+	fmt.Printf("Difficulty:    %s\n", common.BigToAddress(problem.Difficulty))
+	fmt.Printf("Address AB:    %s\n", crypto.PubkeyToAddress(privateKeyAB.PublicKey))
+	if !ok {
+		fmt.Println("Key B is not a solution")
+		os.Exit(1)
+	}
+	fmt.Println("Key B is a solution")
+}
+
+func runSubmitManual(cfg *config.Loaded, args []string) {
+	flags := flag.NewFlagSet("submit-manual", flag.ExitOnError)
+	key := flags.String("key", "", "private key B (hex)")
+	flags.Parse(args)
+
+	privateKeyB, err := parsePrivateKey(*key)
+	if err != nil {
+		log.Fatal("invalid -key: ", err)
+	}
+	if _, err := parsePrivateKey(cfg.PrivateKey); err != nil {
+		log.Fatal("invalid private_key: ", err)
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	conn := dial(ctx, cfg.Config)
+	problem, err := listener.CurrentProblem(ctx, conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	privateKeyA, err := utils.ParsePrivateKey(*problem.PrivateKeyA)
+	if err != nil {
+		log.Fatal(err)
+	}
+	privateKeyAB, ok, err := solver.Verify(*privateKeyA, *privateKeyB, problem.Difficulty)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !ok {
+		log.Fatalf("Key B doesn't solve current problem %s", problem.Nonce)
+	}
+
+	submitter, err := submitterpkg.NewSubmitter(ctx, conn, cfg.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := submitter.Submit(ctx, *privateKeyB, *privateKeyAB)
+	if result != nil {
+		log.Printf("Transaction %s, reverted: %t", result.TxHash, result.Reverted)
+		if result.Submission != nil {
+			log.Printf("Reward: %f INFINITY", accounting.ToEther(result.Submission.Reward))
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"infinity/miner/internal/config"
+	"infinity/miner/internal/farm"
+	"infinity/miner/internal/solver"
+	"log"
+	"os"
+	"runtime"
+)
+
+// runWorker runs solvers for coordinator, it needs neither RPC nor private key.
+func runWorker(cfg *config.Loaded, args []string) {
+	hostname, _ := os.Hostname()
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	name := flags.String("name", hostname, "worker name shown by coordinator")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		log.Fatal("usage: miner [flags] worker [-name name]")
+	}
+	if err := cfg.ValidateFarm(); err != nil {
+		log.Fatal("Invalid config:\n", err)
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	solutionCh := make(chan solver.Solution, runtime.NumCPU())
+	pool := solver.NewPool(ctx, cfg.ReserveCores, cfg.CPUAffinity, solutionCh)
+	solvers, err := pool.Resize(cfg.Threads)
+	if err != nil {
+		log.Fatal("cant start solvers: ", err)
+	}
+	log.Printf("Started %d solvers, connecting to coordinator %s", len(solvers), cfg.CoordinatorAddr)
+
+	if err := farm.RunWorker(ctx, cfg.CoordinatorAddr, cfg.FarmToken, *name, pool, solutionCh); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Bye")
+}